@@ -0,0 +1,108 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package anotest drives anoweb Apps in-process for tests, without listening on a port.
+package anotest
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"testing"
+
+	"github.com/go-the-way/anoweb"
+)
+
+// BaseURL the base url of the requests, scopes the cookies of the jar
+const BaseURL = "http://anotest.local"
+
+// Client drives an App in-process, cookies are kept in the jar across requests
+type Client struct {
+	t       testing.TB
+	app     *anoweb.App
+	handler http.Handler
+	jar     http.CookieJar
+}
+
+// New return new Client of the App
+func New(t testing.TB, app *anoweb.App) *Client {
+	c := NewHandler(t, app.Handler())
+	c.app = app
+	return c
+}
+
+// NewHandler return new Client of the http.Handler
+func NewHandler(t testing.TB, handler http.Handler) *Client {
+	jar, _ := cookiejar.New(nil)
+	return &Client{t: t, handler: handler, jar: jar}
+}
+
+// Jar return the cookie jar
+func (c *Client) Jar() http.CookieJar {
+	return c.jar
+}
+
+// Cookie return the named cookie of the jar
+func (c *Client) Cookie(name string) *http.Cookie {
+	u, _ := url.Parse(BaseURL)
+	for _, cookie := range c.jar.Cookies(u) {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+// ClearCookies clears the cookie jar
+func (c *Client) ClearCookies() *Client {
+	c.jar, _ = cookiejar.New(nil)
+	return c
+}
+
+// Request return new Request of the method
+func (c *Client) Request(method, path string) *Request {
+	return newRequest(c, method, path)
+}
+
+// Get return new Get Request
+func (c *Client) Get(path string) *Request {
+	return c.Request(http.MethodGet, path)
+}
+
+// Post return new Post Request
+func (c *Client) Post(path string) *Request {
+	return c.Request(http.MethodPost, path)
+}
+
+// Put return new Put Request
+func (c *Client) Put(path string) *Request {
+	return c.Request(http.MethodPut, path)
+}
+
+// Delete return new Delete Request
+func (c *Client) Delete(path string) *Request {
+	return c.Request(http.MethodDelete, path)
+}
+
+// Patch return new Patch Request
+func (c *Client) Patch(path string) *Request {
+	return c.Request(http.MethodPatch, path)
+}
+
+// Head return new Head Request
+func (c *Client) Head(path string) *Request {
+	return c.Request(http.MethodHead, path)
+}
+
+// Options return new Options Request
+func (c *Client) Options(path string) *Request {
+	return c.Request(http.MethodOptions, path)
+}
@@ -0,0 +1,88 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anotest
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/go-the-way/anoweb"
+	"github.com/go-the-way/anoweb/context"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeT records failures instead of failing the test
+type fakeT struct {
+	testing.TB
+	errors []string
+	fatal  bool
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *fakeT) Fatalf(format string, args ...interface{}) {
+	t.Errorf(format, args...)
+	t.fatal = true
+}
+
+func TestClientMethods(t *testing.T) {
+	a := anoweb.New().Request("/", func(ctx *context.Context) {
+		ctx.Text(ctx.Request.Method)
+	})
+	c := New(t, a)
+	c.Get("/").Do().AssertStatus(http.StatusOK).AssertBody(http.MethodGet)
+	c.Post("/").Do().AssertBody(http.MethodPost)
+	c.Put("/").Do().AssertBody(http.MethodPut)
+	c.Delete("/").Do().AssertBody(http.MethodDelete)
+	c.Patch("/").Do().AssertBody(http.MethodPatch)
+	c.Options("/").Do().AssertBody(http.MethodOptions)
+	c.Head("/").Do().AssertStatus(http.StatusOK)
+	c.Request(http.MethodGet, "/").Do().AssertBody(http.MethodGet)
+}
+
+func TestClientCookieJar(t *testing.T) {
+	a := anoweb.New()
+	a.Get("/login", func(ctx *context.Context) {
+		ctx.AddCookie(&http.Cookie{Name: "user", Value: "anoweb", Path: "/"})
+	})
+	a.Get("/me", func(ctx *context.Context) {
+		cookie, err := ctx.Request.Cookie("user")
+		if err != nil {
+			ctx.Status(http.StatusUnauthorized)
+			return
+		}
+		ctx.Text(cookie.Value)
+	})
+	c := New(t, a)
+	c.Get("/me").Do().AssertStatus(http.StatusUnauthorized)
+	c.Get("/login").Do().AssertCookie("user", "anoweb")
+	require.Equal(t, "anoweb", c.Cookie("user").Value)
+	require.NotNil(t, c.Jar())
+	c.Get("/me").Do().AssertStatus(http.StatusOK).AssertBody("anoweb")
+	c.ClearCookies()
+	require.Nil(t, c.Cookie("user"))
+	c.Get("/me").Do().AssertStatus(http.StatusUnauthorized)
+}
+
+func TestNewHandler(t *testing.T) {
+	c := NewHandler(t, http.NotFoundHandler())
+	c.Get("/").Do().AssertStatus(http.StatusNotFound)
+	ft := &fakeT{}
+	NewHandler(ft, http.NotFoundHandler()).Get("/").Do().AssertTemplate("hello", nil)
+	require.Len(t, ft.errors, 1)
+}
@@ -0,0 +1,169 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anotest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/go-the-way/anoweb/headers"
+)
+
+type file struct {
+	field   string
+	name    string
+	content []byte
+}
+
+// Request a fluent request builder
+type Request struct {
+	c       *Client
+	method  string
+	path    string
+	header  http.Header
+	query   url.Values
+	form    url.Values
+	files   []*file
+	cookies []*http.Cookie
+	body    []byte
+	bodySet bool
+}
+
+func newRequest(c *Client, method, path string) *Request {
+	return &Request{
+		c:       c,
+		method:  method,
+		path:    path,
+		header:  http.Header{},
+		query:   url.Values{},
+		form:    url.Values{},
+		files:   make([]*file, 0),
+		cookies: make([]*http.Cookie, 0),
+	}
+}
+
+// Header sets the request header
+func (r *Request) Header(key, value string) *Request {
+	r.header.Set(key, value)
+	return r
+}
+
+// Query adds the query param
+func (r *Request) Query(key, value string) *Request {
+	r.query.Add(key, value)
+	return r
+}
+
+// Cookie adds the cookies besides the ones of the jar
+func (r *Request) Cookie(cookies ...*http.Cookie) *Request {
+	r.cookies = append(r.cookies, cookies...)
+	return r
+}
+
+// Body sets the raw body
+func (r *Request) Body(body []byte) *Request {
+	r.body = body
+	r.bodySet = true
+	return r
+}
+
+// JSON sets the body marshalled from v
+func (r *Request) JSON(v interface{}) *Request {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		r.c.t.Fatalf("anotest: marshal json body: %v", err)
+	}
+	r.header.Set(headers.MIME, "application/json")
+	return r.Body(bs)
+}
+
+// Form adds the form field, sent url-encoded or as a multipart field when files are added
+func (r *Request) Form(key, value string) *Request {
+	r.form.Add(key, value)
+	return r
+}
+
+// File adds the multipart file
+func (r *Request) File(field, name string, content []byte) *Request {
+	r.files = append(r.files, &file{field, name, content})
+	return r
+}
+
+// Build return the built http.Request
+func (r *Request) Build() *http.Request {
+	var body io.Reader
+	contentType := ""
+	switch {
+	case r.bodySet:
+		body = bytes.NewReader(r.body)
+	case len(r.files) > 0:
+		buf := &bytes.Buffer{}
+		mw := multipart.NewWriter(buf)
+		for key, values := range r.form {
+			for _, value := range values {
+				_ = mw.WriteField(key, value)
+			}
+		}
+		for _, f := range r.files {
+			fw, err := mw.CreateFormFile(f.field, f.name)
+			if err != nil {
+				r.c.t.Fatalf("anotest: create form file: %v", err)
+			}
+			_, _ = fw.Write(f.content)
+		}
+		_ = mw.Close()
+		body = buf
+		contentType = mw.FormDataContentType()
+	case len(r.form) > 0:
+		body = strings.NewReader(r.form.Encode())
+		contentType = "application/x-www-form-urlencoded"
+	}
+	target := BaseURL + r.path
+	if len(r.query) > 0 {
+		sep := "?"
+		if strings.Contains(target, "?") {
+			sep = "&"
+		}
+		target += sep + r.query.Encode()
+	}
+	req := httptest.NewRequest(r.method, target, body)
+	if contentType != "" {
+		req.Header.Set(headers.MIME, contentType)
+	}
+	for key, values := range r.header {
+		req.Header[key] = values
+	}
+	for _, cookie := range r.c.jar.Cookies(req.URL) {
+		req.AddCookie(cookie)
+	}
+	for _, cookie := range r.cookies {
+		req.AddCookie(cookie)
+	}
+	return req
+}
+
+// Do sends the request in-process, cookies of the response are stored in the jar
+func (r *Request) Do() *Response {
+	r.c.t.Helper()
+	req := r.Build()
+	rec := httptest.NewRecorder()
+	r.c.handler.ServeHTTP(rec, req)
+	resp := rec.Result()
+	r.c.jar.SetCookies(req.URL, resp.Cookies())
+	return newResponse(r.c, resp, rec.Body.Bytes())
+}
@@ -0,0 +1,81 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anotest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/go-the-way/anoweb"
+	"github.com/go-the-way/anoweb/context"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestBuild(t *testing.T) {
+	c := NewHandler(t, http.NotFoundHandler())
+	{
+		req := c.Get("/a?b=1").Query("c", "2").Header("X-A", "a").Cookie(&http.Cookie{Name: "k", Value: "v"}).Build()
+		require.Equal(t, "/a", req.URL.Path)
+		require.Equal(t, "1", req.URL.Query().Get("b"))
+		require.Equal(t, "2", req.URL.Query().Get("c"))
+		require.Equal(t, "a", req.Header.Get("X-A"))
+		cookie, err := req.Cookie("k")
+		require.Nil(t, err)
+		require.Equal(t, "v", cookie.Value)
+	}
+	{
+		req := c.Post("/").JSON(map[string]int{"a": 1}).Build()
+		require.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		body, _ := ioutil.ReadAll(req.Body)
+		require.Equal(t, `{"a":1}`, string(body))
+	}
+	{
+		req := c.Post("/").Form("a", "1").Build()
+		require.Equal(t, "application/x-www-form-urlencoded", req.Header.Get("Content-Type"))
+		require.Nil(t, req.ParseForm())
+		require.Equal(t, "1", req.PostForm.Get("a"))
+	}
+	{
+		req := c.Post("/").Form("a", "1").File("f", "f.txt", []byte("file")).Build()
+		require.Nil(t, req.ParseMultipartForm(1<<20))
+		require.Equal(t, "1", req.MultipartForm.Value["a"][0])
+		require.Equal(t, "f.txt", req.MultipartForm.File["f"][0].Filename)
+	}
+	{
+		ft := &fakeT{}
+		NewHandler(ft, http.NotFoundHandler()).Post("/").JSON(func() {})
+		require.True(t, ft.fatal)
+	}
+}
+
+func TestRequestDo(t *testing.T) {
+	type user struct {
+		Name string `json:"name"`
+	}
+	a := anoweb.New()
+	a.Post("/json", func(ctx *context.Context) {
+		u := &user{}
+		ctx.Bind(u)
+		ctx.JSON(u)
+	})
+	a.Post("/upload", func(ctx *context.Context) {
+		_ = ctx.ParseMultipart(1 << 20)
+		f, _ := ctx.MultipartFile("f").Open()
+		content, _ := ioutil.ReadAll(f)
+		ctx.Text(ctx.Param("a") + ":" + string(content))
+	})
+	c := New(t, a)
+	c.Post("/json").JSON(&user{"anoweb"}).Do().AssertStatus(http.StatusOK).AssertJSON("name", "anoweb")
+	c.Post("/upload").Form("a", "1").File("f", "f.txt", []byte("file")).Do().AssertBody("1:file")
+}
@@ -0,0 +1,197 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anotest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-the-way/anoweb/context"
+)
+
+// Response the recorded response with assertion helpers, failed assertions are reported by testing.TB.Errorf
+type Response struct {
+	*http.Response
+	c    *Client
+	body []byte
+}
+
+func newResponse(c *Client, resp *http.Response, body []byte) *Response {
+	return &Response{resp, c, body}
+}
+
+// Bytes return the body
+func (r *Response) Bytes() []byte {
+	return r.body
+}
+
+// String return the body as string
+func (r *Response) String() string {
+	return string(r.body)
+}
+
+// Decode decodes the json body into v
+func (r *Response) Decode(v interface{}) error {
+	return json.Unmarshal(r.body, v)
+}
+
+// AssertStatus asserts the status code
+func (r *Response) AssertStatus(status int) *Response {
+	r.c.t.Helper()
+	if r.StatusCode != status {
+		r.c.t.Errorf("anotest: expected status %d, got %d", status, r.StatusCode)
+	}
+	return r
+}
+
+// AssertHeader asserts the header value
+func (r *Response) AssertHeader(key, value string) *Response {
+	r.c.t.Helper()
+	if got := r.Header.Get(key); got != value {
+		r.c.t.Errorf("anotest: expected header %s %q, got %q", key, value, got)
+	}
+	return r
+}
+
+// AssertHeaderContains asserts the header value contains sub
+func (r *Response) AssertHeaderContains(key, sub string) *Response {
+	r.c.t.Helper()
+	if got := r.Header.Get(key); !strings.Contains(got, sub) {
+		r.c.t.Errorf("anotest: expected header %s containing %q, got %q", key, sub, got)
+	}
+	return r
+}
+
+// AssertCookie asserts the response sets the cookie value
+func (r *Response) AssertCookie(name, value string) *Response {
+	r.c.t.Helper()
+	for _, cookie := range r.Cookies() {
+		if cookie.Name == name {
+			if cookie.Value != value {
+				r.c.t.Errorf("anotest: expected cookie %s %q, got %q", name, value, cookie.Value)
+			}
+			return r
+		}
+	}
+	r.c.t.Errorf("anotest: expected cookie %s, got none", name)
+	return r
+}
+
+// AssertBody asserts the body
+func (r *Response) AssertBody(body string) *Response {
+	r.c.t.Helper()
+	if got := r.String(); got != body {
+		r.c.t.Errorf("anotest: expected body %q, got %q", body, got)
+	}
+	return r
+}
+
+// AssertBodyContains asserts the body contains sub
+func (r *Response) AssertBodyContains(sub string) *Response {
+	r.c.t.Helper()
+	if !strings.Contains(r.String(), sub) {
+		r.c.t.Errorf("anotest: expected body containing %q, got %q", sub, r.String())
+	}
+	return r
+}
+
+// AssertJSON asserts the json value at the dotted path, e.g. data.items.0.name, an empty path is the whole body.
+//
+// Expected is compared after a json round trip, so numbers and structs compare as decoded json.
+func (r *Response) AssertJSON(path string, expected interface{}) *Response {
+	r.c.t.Helper()
+	var doc interface{}
+	if err := json.Unmarshal(r.body, &doc); err != nil {
+		r.c.t.Errorf("anotest: decode json body: %v", err)
+		return r
+	}
+	got, err := JSONPath(doc, path)
+	if err != nil {
+		r.c.t.Errorf("anotest: %v", err)
+		return r
+	}
+	bs, err := json.Marshal(expected)
+	if err != nil {
+		r.c.t.Errorf("anotest: marshal expected: %v", err)
+		return r
+	}
+	var want interface{}
+	_ = json.Unmarshal(bs, &want)
+	if !reflect.DeepEqual(want, got) {
+		r.c.t.Errorf("anotest: expected json %s %v, got %v", path, want, got)
+	}
+	return r
+}
+
+// AssertTemplate asserts the body is the template file rendered with data,
+// using the template config and the named routes of the App, as context.Context.TemplateFile does.
+func (r *Response) AssertTemplate(prefix string, data map[string]interface{}) *Response {
+	r.c.t.Helper()
+	if r.c.app == nil {
+		r.c.t.Errorf("anotest: AssertTemplate requires a Client of an App")
+		return r
+	}
+	expected, err := renderTemplate(r.c, prefix, data)
+	if err != nil {
+		r.c.t.Errorf("anotest: render template %s: %v", prefix, err)
+		return r
+	}
+	if got := r.String(); got != expected {
+		r.c.t.Errorf("anotest: expected template %s %q, got %q", prefix, expected, got)
+	}
+	return r
+}
+
+func renderTemplate(c *Client, prefix string, data map[string]interface{}) (tpl string, err error) {
+	defer func() {
+		if re := recover(); re != nil {
+			err = fmt.Errorf("%v", re)
+		}
+	}()
+	ctx := context.New()
+	ctx.Allocate(httptest.NewRequest(http.MethodGet, BaseURL, nil), c.app.Config.Template)
+	ctx.SetURLFunc(c.app.URL)
+	ctx.TemplateFile(prefix, data)
+	return string(ctx.Response.Data), nil
+}
+
+// JSONPath return the value at the dotted path of the decoded json doc
+func JSONPath(doc interface{}, path string) (interface{}, error) {
+	if path == "" {
+		return doc, nil
+	}
+	v := doc
+	for _, key := range strings.Split(path, ".") {
+		switch vv := v.(type) {
+		case map[string]interface{}:
+			val, have := vv[key]
+			if !have {
+				return nil, fmt.Errorf("json path %s: key %s not found", path, key)
+			}
+			v = val
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(vv) {
+				return nil, fmt.Errorf("json path %s: index %s out of range", path, key)
+			}
+			v = vv[i]
+		default:
+			return nil, fmt.Errorf("json path %s: %s is not an object or array", path, key)
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anotest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-the-way/anoweb"
+	"github.com/go-the-way/anoweb/context"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testResponseApp() *anoweb.App {
+	a := anoweb.New()
+	a.Config.Template.Root = "testdata"
+	a.Get("/json", func(ctx *context.Context) {
+		ctx.Header(http.Header{"X-Version": {"1.0.0"}})
+		ctx.JSON(map[string]interface{}{"data": map[string]interface{}{"items": []map[string]interface{}{{"id": 1, "name": "a"}}}})
+	})
+	a.Get("/template", func(ctx *context.Context) {
+		ctx.TemplateFile("hello", map[string]interface{}{"name": "anoweb"})
+	}).Name("template")
+	a.Get("/link", func(ctx *context.Context) {
+		ctx.TemplateFile("link", nil)
+	})
+	return a
+}
+
+func TestResponseAssert(t *testing.T) {
+	c := New(t, testResponseApp())
+	resp := c.Get("/json").Do().
+		AssertStatus(http.StatusOK).
+		AssertHeader("X-Version", "1.0.0").
+		AssertHeaderContains("Content-Type", "json").
+		AssertBodyContains("items").
+		AssertJSON("data.items.0.id", 1).
+		AssertJSON("data.items.0", map[string]interface{}{"id": 1, "name": "a"})
+	v := map[string]interface{}{}
+	require.Nil(t, resp.Decode(&v))
+	require.Contains(t, v, "data")
+	require.Equal(t, resp.String(), string(resp.Bytes()))
+	c.Get("/template").Do().AssertTemplate("hello", map[string]interface{}{"name": "anoweb"}).AssertBody("<p>anoweb</p>")
+	c.Get("/link").Do().AssertTemplate("link", nil).AssertBody(`<a href="/template">anoweb</a>`)
+}
+
+func TestResponseAssertFail(t *testing.T) {
+	ft := &fakeT{}
+	c := New(ft, testResponseApp())
+	c.Get("/json").Do().
+		AssertStatus(http.StatusCreated).
+		AssertHeader("X-Version", "2").
+		AssertHeaderContains("Content-Type", "xml").
+		AssertCookie("a", "b").
+		AssertBody("").
+		AssertBodyContains("nothing").
+		AssertJSON("data.items.0.id", 2).
+		AssertJSON("data.items.1", nil).
+		AssertJSON("data.none", nil).
+		AssertJSON("data.items.0.id.x", nil)
+	require.Len(t, ft.errors, 10)
+	ft.errors = nil
+	c.Get("/template").Do().
+		AssertJSON("", nil).
+		AssertTemplate("hello", map[string]interface{}{"name": "go"}).
+		AssertTemplate("missing", nil)
+	require.Len(t, ft.errors, 3)
+}
+
+func TestJSONPath(t *testing.T) {
+	doc := map[string]interface{}{"a": []interface{}{map[string]interface{}{"b": "c"}}}
+	v, err := JSONPath(doc, "a.0.b")
+	require.Nil(t, err)
+	require.Equal(t, "c", v)
+	v, err = JSONPath(doc, "")
+	require.Nil(t, err)
+	require.Equal(t, doc, v)
+	_, err = JSONPath(doc, "a.x")
+	require.Error(t, err)
+}
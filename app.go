@@ -12,33 +12,66 @@
 package anoweb
 
 import (
-	"fmt"
+	stdctx "context"
+	"errors"
 	"github.com/go-the-way/anoweb/config"
 	"github.com/go-the-way/anoweb/context"
 	"github.com/go-the-way/anoweb/middleware"
 	"github.com/go-the-way/anoweb/rest"
 	"github.com/go-the-way/anoweb/router"
+	"github.com/go-the-way/anoweb/session"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
 	"sync"
+	"syscall"
+)
+
+var (
+	// ErrAppStarted is returned when Start or Run is called on a running App
+	ErrAppStarted = errors.New("anoweb: app already started")
+	// ErrAppNotStarted is returned when Shutdown is called before Start
+	ErrAppNotStarted = errors.New("anoweb: app not started")
 )
 
 // App struct
 type App struct {
-	logger         *log.Logger
-	ConfigFile     string
-	Config         *config.Config
-	controllers    []rest.Controller
-	groups         []*router.Group
-	routers        []*router.Router
-	parsedRouters  *router.ParsedRouter
-	middlewares    []middleware.Middleware
-	defaultMWState *defaultMWState
-	ctxPool        *sync.Pool
+	logger          *log.Logger
+	ConfigFile      string
+	Config          *config.Config
+	profiles        []string
+	configDoc       map[string]interface{}
+	configBinds     []*configBind
+	configSources   map[string]string
+	controllers     []rest.Controller
+	controllerSites []string
+	groups          []*router.Group
+	routers         []*router.Router
+	parsedRouters   *router.ParsedRouter
+	registrations   map[string]*router.Registration
+	routeKeys       map[string]string
+	routeNames      map[string]*router.Registration
+	routes          map[string]*RouteInfo
+	urlFunc         func(name string, params ...interface{}) (string, error)
+	routeErr        error
+	fallback        *router.Fallback
+	errorHandler    func(ctx *context.Context, err error)
+	middlewares     []middleware.Middleware
+	defaultMWState  *defaultMWState
+	ctxPool         *sync.Pool
+	mu              *sync.Mutex
+	serverHooks     []func(server *http.Server)
+	servers         []*http.Server
+	certReloader    *certReloader
+	prepared        bool
+	serveErr        chan error
+	shutdownDone    chan struct{}
+	shutdownErr     error
 }
 
 // Default the default App
@@ -46,45 +79,278 @@ var Default = New()
 
 // New return new App
 func New() *App {
-	return &App{
+	a := &App{
 		logger:         log.New(os.Stdout, "[anoweb] ", log.LstdFlags),
 		ConfigFile:     "app.yml",
 		Config:         config.Default(),
+		profiles:       make([]string, 0),
+		configBinds:    make([]*configBind, 0),
 		controllers:    make([]rest.Controller, 0),
 		groups:         make([]*router.Group, 0),
 		routers:        []*router.Router{router.NewRouter()},
 		parsedRouters:  &router.ParsedRouter{Simples: make(router.SimpleM), Dynamics: make(router.DynamicM)},
+		registrations:  make(map[string]*router.Registration),
+		routeKeys:      make(map[string]string),
+		routeNames:     make(map[string]*router.Registration),
+		routes:         make(map[string]*RouteInfo),
+		fallback:       &router.Fallback{},
+		errorHandler:   context.DefaultErrorHandler,
 		middlewares:    make([]middleware.Middleware, 6),
 		defaultMWState: &defaultMWState{header: true, faviconFile: "favicon.ico", faviconRoute: "/favicon.ico"},
-		ctxPool:        &sync.Pool{New: func() interface{} { return context.New() }}}
+		ctxPool:        &sync.Pool{New: func() interface{} { return &context.Context{} }},
+		mu:             &sync.Mutex{}}
+	a.urlFunc = a.URL
+	return a
+}
+
+// Run App, blocks until the server stops or SIGINT/SIGTERM is received.
+//
+// On signal the server stops accepting connections and drains active requests
+// within Config.Server.ShutdownTimeout.
+func (a *App) Run() error {
+	return a.run(nil)
+}
+
+// Serve App on the listener ln, blocks like Run
+func (a *App) Serve(ln net.Listener) error {
+	return a.run(ln)
+}
+
+// Start App without blocking, the server runs in background until Shutdown is called
+func (a *App) Start() error {
+	return a.start(nil)
 }
 
-// Run App
-func (a *App) Run() {
-	a.parseYml()
-	a.parseEnv()
+func (a *App) run(ln net.Listener) error {
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(quit)
+	if err := a.start(ln); err != nil {
+		return err
+	}
+	select {
+	case err := <-a.serveErr:
+		if err != http.ErrServerClosed {
+			ctx, cancel := stdctx.WithTimeout(stdctx.Background(), a.Config.Server.ShutdownTimeout)
+			defer cancel()
+			_ = a.Shutdown(ctx)
+			return err
+		}
+		<-a.shutdownDone
+		return a.shutdownErr
+	case sig := <-quit:
+		a.logger.Printf("Received signal %v, shutting down\n", sig)
+		ctx, cancel := stdctx.WithTimeout(stdctx.Background(), a.Config.Server.ShutdownTimeout)
+		defer cancel()
+		return a.Shutdown(ctx)
+	}
+}
+
+func (a *App) start(ln net.Listener) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.servers != nil {
+		return ErrAppStarted
+	}
+	if err := a.parseYml(); err != nil {
+		return err
+	}
+	if err := a.parseEnv(); err != nil {
+		return err
+	}
+	if err := a.Config.Validate(); err != nil {
+		return err
+	}
+	if err := a.bindConfigs(); err != nil {
+		return err
+	}
 	a.printBanner()
 	a.printVendor()
-	a.routeRestControllers()
-	a.useDefaultMWs()
-	a.parseRouters()
-	a.serve()
-}
-
-func (a *App) serve() {
-	host := a.Config.Server.Host
-	port := a.Config.Server.Port
-	tlsEnable := a.Config.Server.TLS.Enable
-	addr := host + ":" + strconv.Itoa(port)
-	server := &http2.Server{}
-	handler := h2c.NewHandler(a.newDispatcher(), server)
-	if tlsEnable {
-		certFile := a.Config.Server.TLS.CertFile
-		keyFile := a.Config.Server.TLS.KeyFile
-		a.logger.Printf("Server started on https://%s\n", addr)
-		_, _ = fmt.Fprintln(os.Stderr, http.ListenAndServeTLS(addr, certFile, keyFile, handler))
+	a.printConfig()
+	if err := a.prepare(); err != nil {
+		return err
+	}
+	a.printRoutes()
+	return a.serve(ln)
+}
+
+// Handler return the App as a http.Handler, for embedding in an existing server or httptest.
+//
+// Routes are parsed and default middlewares applied on the first call,
+// routes added afterwards are not served. Config is used as is, app.yml and env are not loaded.
+// Panics with the *router.ConflictError if the routes conflict.
+func (a *App) Handler() http.Handler {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.prepare(); err != nil {
+		panic(err)
+	}
+	return a.newDispatcher()
+}
+
+// prepare routes and middlewares once, return the route conflict
+func (a *App) prepare() error {
+	if !a.prepared {
+		a.prepared = true
+		a.routeRestControllers()
+		a.useDefaultMWs()
+		a.parseRouters()
+	}
+	return a.routeErr
+}
+
+// Shutdown App gracefully, stops accepting connections and waits for active requests
+// until ctx is done, then closes the remaining connections and stops the session provider.
+func (a *App) Shutdown(ctx stdctx.Context) error {
+	a.mu.Lock()
+	servers := a.servers
+	done := a.shutdownDone
+	a.mu.Unlock()
+	if servers == nil {
+		return ErrAppNotStarted
+	}
+	var err error
+	for _, server := range servers {
+		if sErr := server.Shutdown(ctx); sErr != nil {
+			_ = server.Close()
+			if err == nil {
+				err = sErr
+			}
+		}
+	}
+	a.stopSession()
+	if a.certReloader != nil {
+		a.certReloader.Stop()
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	select {
+	case <-done:
+	default:
+		a.shutdownErr = err
+		close(done)
+		a.logger.Println("Server stopped")
+	}
+	return err
+}
+
+func (a *App) stopSession() {
+	if stopper, ok := a.defaultMWState.sessionProvider.(session.Stopper); ok {
+		stopper.Stop()
+	}
+}
+
+// ServerHook adds hooks called with the fully configured *http.Server before it starts serving
+func (a *App) ServerHook(hooks ...func(server *http.Server)) *App {
+	a.serverHooks = append(a.serverHooks, hooks...)
+	return a
+}
+
+func (a *App) newHTTP2Server() *http2.Server {
+	h2s := &http2.Server{}
+	if hc := a.Config.Server.HTTP2; hc != nil {
+		h2s.MaxConcurrentStreams = hc.MaxConcurrentStreams
+		h2s.MaxReadFrameSize = hc.MaxReadFrameSize
+		h2s.IdleTimeout = hc.IdleTimeout
+		h2s.MaxUploadBufferPerConnection = hc.MaxUploadBufferPerConnection
+		h2s.MaxUploadBufferPerStream = hc.MaxUploadBufferPerStream
+	}
+	return h2s
+}
+
+func (a *App) newServer(addr string, handler http.Handler) *http.Server {
+	sc := a.Config.Server
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		MaxHeaderBytes:    sc.MaxHeaderSize,
+		ReadTimeout:       sc.ReadTimeout,
+		ReadHeaderTimeout: sc.ReadHeaderTimeout,
+		WriteTimeout:      sc.WriteTimeout,
+		IdleTimeout:       sc.IdleTimeout,
+	}
+}
+
+func (a *App) newServers(addr string) ([]*http.Server, error) {
+	tc := a.Config.Server.TLS
+	h2s := a.newHTTP2Server()
+	if !tc.Enable {
+		return a.hookServers(a.newServer(addr, h2c.NewHandler(a.newDispatcher(), h2s))), nil
+	}
+	server := a.newServer(addr, a.hstsHandler(a.newDispatcher()))
+	tlsConfig, err := a.newTLSConfig(tc)
+	if err != nil {
+		return nil, err
+	}
+	server.TLSConfig = tlsConfig
+	if err = http2.ConfigureServer(server, h2s); err != nil {
+		return nil, err
+	}
+	if tc.HTTPPort <= 0 {
+		return a.hookServers(server), nil
+	}
+	httpAddr := a.Config.Server.Host + ":" + strconv.Itoa(tc.HTTPPort)
+	var httpHandler http.Handler
+	if tc.Redirect {
+		httpHandler = a.redirectHandler(server)
 	} else {
-		a.logger.Printf("Server started on http://%s\n", addr)
-		_, _ = fmt.Fprintln(os.Stderr, http.ListenAndServe(addr, handler))
+		httpHandler = h2c.NewHandler(a.newDispatcher(), h2s)
+	}
+	return a.hookServers(server, a.newServer(httpAddr, httpHandler)), nil
+}
+
+func (a *App) hookServers(servers ...*http.Server) []*http.Server {
+	for _, server := range servers {
+		for _, hook := range a.serverHooks {
+			hook(server)
+		}
+	}
+	return servers
+}
+
+func (a *App) serve(ln net.Listener) error {
+	servers, err := a.newServers(a.Config.Server.Host + ":" + strconv.Itoa(a.Config.Server.Port))
+	if err != nil {
+		return err
+	}
+	listeners := make([]net.Listener, len(servers))
+	if ln == nil {
+		ln, err = a.listen()
+	}
+	listeners[0] = ln
+	for i := 1; err == nil && i < len(servers); i++ {
+		if listeners[i], err = net.Listen("tcp", servers[i].Addr); err != nil {
+			for _, l := range listeners[:i] {
+				_ = l.Close()
+			}
+		}
+	}
+	if err != nil {
+		if a.certReloader != nil {
+			a.certReloader.Stop()
+		}
+		return err
+	}
+	a.servers = servers
+	a.serveErr = make(chan error, len(servers))
+	a.shutdownDone = make(chan struct{})
+	for i, server := range servers {
+		server.Addr = listeners[i].Addr().String()
+		scheme := "http"
+		if server.TLSConfig != nil {
+			scheme = "https"
+		}
+		if listeners[i].Addr().Network() == "unix" {
+			scheme += "+unix"
+		}
+		a.logger.Printf("Server started on %s://%s\n", scheme, server.Addr)
+		go func(server *http.Server, ln net.Listener) {
+			if server.TLSConfig != nil {
+				a.serveErr <- server.ServeTLS(ln, "", "")
+			} else {
+				a.serveErr <- server.Serve(ln)
+			}
+		}(server, listeners[i])
 	}
+	return nil
 }
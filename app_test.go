@@ -12,11 +12,18 @@
 package anoweb
 
 import (
+	stdctx "context"
+	"fmt"
+	"github.com/go-the-way/anoweb/context"
 	"github.com/stretchr/testify/require"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/http/pprof"
 	"os"
+	"strings"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -72,6 +79,179 @@ func TestAppRunWithTLS(t *testing.T) {
 	_ = conn.Close()
 }
 
+func testAppEnv(port int) {
+	_ = os.Setenv(envServerHost, "localhost")
+	_ = os.Setenv(envServerPort, fmt.Sprintf("%d", port))
+	_ = os.Setenv(envServerTLSEnable, "false")
+}
+
+func TestAppStartShutdown(t *testing.T) {
+	port := nextPort()
+	testAppEnv(port)
+	started := make(chan struct{})
+	a := New().Get("/slow", func(ctx *context.Context) {
+		close(started)
+		time.Sleep(time.Millisecond * 300)
+		ctx.Text("done")
+	})
+	require.Nil(t, a.Start())
+	require.Equal(t, ErrAppStarted, a.Start())
+	respCh := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(fmt.Sprintf("http://localhost:%d/slow", port))
+		if err != nil {
+			respCh <- err.Error()
+			return
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		respCh <- string(body)
+	}()
+	<-started
+	require.Nil(t, a.Shutdown(stdctx.Background()))
+	require.Equal(t, "done", <-respCh)
+	_, err := net.DialTimeout("tcp", fmt.Sprintf("localhost:%d", port), time.Second)
+	require.NotNil(t, err)
+}
+
+func TestAppShutdownTimeout(t *testing.T) {
+	port := nextPort()
+	testAppEnv(port)
+	started := make(chan struct{})
+	a := New().Get("/slow", func(ctx *context.Context) {
+		close(started)
+		time.Sleep(time.Second)
+	})
+	require.Nil(t, a.Start())
+	go func() { _, _ = http.Get(fmt.Sprintf("http://localhost:%d/slow", port)) }()
+	<-started
+	ctx, cancel := stdctx.WithTimeout(stdctx.Background(), time.Millisecond*100)
+	defer cancel()
+	require.Equal(t, stdctx.DeadlineExceeded, a.Shutdown(ctx))
+}
+
+func TestAppShutdownNotStarted(t *testing.T) {
+	require.Equal(t, ErrAppNotStarted, New().Shutdown(stdctx.Background()))
+}
+
+func TestAppRunSignal(t *testing.T) {
+	port := nextPort()
+	testAppEnv(port)
+	errCh := make(chan error, 1)
+	go func() { errCh <- New().Run() }()
+	for i := 0; i < 50; i++ {
+		if conn, err := net.DialTimeout("tcp", fmt.Sprintf("localhost:%d", port), time.Second); err == nil {
+			_ = conn.Close()
+			break
+		}
+		time.Sleep(time.Millisecond * 20)
+	}
+	p, _ := os.FindProcess(os.Getpid())
+	require.Nil(t, p.Signal(syscall.SIGTERM))
+	select {
+	case err := <-errCh:
+		require.Nil(t, err)
+	case <-time.After(time.Second * 5):
+		t.Error("test fail: timeout")
+	}
+}
+
+func TestAppRunError(t *testing.T) {
+	port := nextPort()
+	testAppEnv(port)
+	ln, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", port))
+	require.Nil(t, err)
+	defer func() { _ = ln.Close() }()
+	require.NotNil(t, New().Run())
+}
+
+func TestAppNewServer(t *testing.T) {
+	a := New()
+	a.Config.Server.MaxHeaderSize = 1024
+	a.Config.Server.ReadTimeout = time.Second
+	a.Config.Server.ReadHeaderTimeout = time.Second * 2
+	a.Config.Server.WriteTimeout = time.Second * 3
+	a.Config.Server.IdleTimeout = time.Second * 4
+	servers, err := a.newServers("localhost:0")
+	require.Nil(t, err)
+	require.Len(t, servers, 1)
+	server := servers[0]
+	require.Equal(t, "localhost:0", server.Addr)
+	require.Equal(t, 1024, server.MaxHeaderBytes)
+	require.Equal(t, time.Second, server.ReadTimeout)
+	require.Equal(t, time.Second*2, server.ReadHeaderTimeout)
+	require.Equal(t, time.Second*3, server.WriteTimeout)
+	require.Equal(t, time.Second*4, server.IdleTimeout)
+	require.Nil(t, server.TLSConfig)
+}
+
+func TestAppNewServerWithTLS(t *testing.T) {
+	_ = ioutil.WriteFile("server.crt", []byte(certFile), 0700)
+	_ = ioutil.WriteFile("server.key", []byte(keyFile), 0700)
+	defer func() {
+		_ = os.Remove("server.crt")
+		_ = os.Remove("server.key")
+	}()
+	a := New()
+	a.Config.Server.TLS.Enable = true
+	a.Config.Server.TLS.CertFile = "server.crt"
+	a.Config.Server.TLS.KeyFile = "server.key"
+	servers, err := a.newServers("localhost:0")
+	require.Nil(t, err)
+	server := servers[0]
+	cert, err := server.TLSConfig.GetCertificate(nil)
+	require.Nil(t, err)
+	require.NotNil(t, cert)
+	require.Contains(t, server.TLSConfig.NextProtos, "h2")
+	require.NotNil(t, server.TLSNextProto["h2"])
+	a.Config.Server.TLS.KeyFile = "none.key"
+	_, err = a.newServers("localhost:0")
+	require.NotNil(t, err)
+}
+
+func TestAppNewHTTP2Server(t *testing.T) {
+	a := New()
+	a.Config.Server.HTTP2.MaxConcurrentStreams = 10
+	a.Config.Server.HTTP2.MaxReadFrameSize = 1 << 15
+	a.Config.Server.HTTP2.IdleTimeout = time.Second
+	a.Config.Server.HTTP2.MaxUploadBufferPerConnection = 1 << 20
+	a.Config.Server.HTTP2.MaxUploadBufferPerStream = 1 << 16
+	h2s := a.newHTTP2Server()
+	require.Equal(t, uint32(10), h2s.MaxConcurrentStreams)
+	require.Equal(t, uint32(1<<15), h2s.MaxReadFrameSize)
+	require.Equal(t, time.Second, h2s.IdleTimeout)
+	require.Equal(t, int32(1<<20), h2s.MaxUploadBufferPerConnection)
+	require.Equal(t, int32(1<<16), h2s.MaxUploadBufferPerStream)
+}
+
+func TestAppServerHook(t *testing.T) {
+	called := 0
+	a := New().ServerHook(func(server *http.Server) {
+		called++
+		server.ReadTimeout = time.Hour
+	}, func(server *http.Server) {
+		called++
+	})
+	servers, err := a.newServers("localhost:0")
+	require.Nil(t, err)
+	require.Equal(t, 2, called)
+	require.Equal(t, time.Hour, servers[0].ReadTimeout)
+}
+
+func TestAppMaxHeaderSize(t *testing.T) {
+	port := nextPort()
+	testAppEnv(port)
+	_ = os.Setenv(envServerMaxHeaderSize, "1")
+	defer func() { _ = os.Unsetenv(envServerMaxHeaderSize) }()
+	a := New().Get("/", func(ctx *context.Context) { ctx.Text("ok") })
+	require.Nil(t, a.Start())
+	defer func() { _ = a.Shutdown(stdctx.Background()) }()
+	req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost:%d/", port), nil)
+	req.Header.Set("X-Large", strings.Repeat("a", 8192))
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusRequestHeaderFieldsTooLarge, resp.StatusCode)
+}
+
 const (
 	certFile = `-----BEGIN CERTIFICATE-----
 MIIDETCCAfkCFHyMmBP9DYIZRoqW17cQvSupfKISMA0GCSqGSIb3DQEBCwUAMEUx
@@ -120,3 +300,56 @@ KjOVgp30TxXY/+XTc8h/qG5LeBga9Ul3eW6hoCr6l4Sdez+JeYRn0r8JIX22ClL3
 lB0CQqCfRU9GQjCMkgx/FpQ0Al1w70tDuDspjQxZgnPxp7/Nv4YKAA==
 -----END RSA PRIVATE KEY-----`
 )
+
+func TestAppStartInvalidConfig(t *testing.T) {
+	testAppEnv(nextPort())
+	_ = os.Setenv(envBannerType, "fancy")
+	defer func() { _ = os.Unsetenv(envBannerType) }()
+	a := New()
+	err := a.Start()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "banner.type")
+	require.Equal(t, ErrAppNotStarted, a.Shutdown(stdctx.Background()))
+}
+
+func TestAppHandler(t *testing.T) {
+	a := New()
+	a.Get("/hello", func(ctx *context.Context) { ctx.Text("hello") })
+	a.Mount("/debug/pprof", http.HandlerFunc(pprof.Index))
+	a.Mount("/std", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Std", r.URL.Path)
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"std":true}`))
+	}))
+	h := a.Handler()
+	simples, mws := len(a.parsedRouters.Simples), len(a.Middlewares())
+	_ = a.Handler()
+	require.Len(t, a.parsedRouters.Simples, simples)
+	require.Len(t, a.Middlewares(), mws)
+	server := httptest.NewServer(h)
+	defer server.Close()
+	get := func(path string) (*http.Response, string) {
+		resp, err := http.Get(server.URL + path)
+		require.Nil(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+	{
+		resp, body := get("/hello")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "hello", body)
+	}
+	{
+		resp, body := get("/std/a/b")
+		require.Equal(t, http.StatusAccepted, resp.StatusCode)
+		require.Equal(t, "/std/a/b", resp.Header.Get("X-Std"))
+		require.Equal(t, `{"std":true}`, body)
+	}
+	{
+		resp, body := get("/debug/pprof/")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Contains(t, resp.Header.Get("Content-Type"), "text/html")
+		require.Contains(t, body, "goroutine")
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anoweb
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-the-way/anoweb/config"
+)
+
+// certReloader holds the server certificate and swaps it when the cert/key files change
+type certReloader struct {
+	certFile string
+	keyFile  string
+	mu       *sync.RWMutex
+	cert     *tls.Certificate
+	modTime  time.Time
+	stop     chan struct{}
+	once     *sync.Once
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	c := &certReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		mu:       &sync.RWMutex{},
+		stop:     make(chan struct{}),
+		once:     &sync.Once{},
+	}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate
+func (c *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cert, nil
+}
+
+func (c *certReloader) lastModified() time.Time {
+	var modTime time.Time
+	for _, file := range []string{c.certFile, c.keyFile} {
+		if fi, err := os.Stat(file); err == nil && fi.ModTime().After(modTime) {
+			modTime = fi.ModTime()
+		}
+	}
+	return modTime
+}
+
+func (c *certReloader) load() error {
+	modTime := c.lastModified()
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cert = &cert
+	c.modTime = modTime
+	return nil
+}
+
+// watch polls the cert/key files every interval, keeps the current certificate if reloading fails
+func (c *certReloader) watch(interval time.Duration, logger *log.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.mu.RLock()
+			modTime := c.modTime
+			c.mu.RUnlock()
+			if !c.lastModified().After(modTime) {
+				continue
+			}
+			if err := c.load(); err != nil {
+				logger.Printf("TLS certificate reload failed: %v\n", err)
+			} else {
+				logger.Printf("TLS certificate reloaded from %s\n", c.certFile)
+			}
+		}
+	}
+}
+
+// Stop watching
+func (c *certReloader) Stop() {
+	c.once.Do(func() { close(c.stop) })
+}
+
+// newTLSConfig return the server tls.Config, watches the certificate files if enabled
+func (a *App) newTLSConfig(tc *config.TLS) (*tls.Config, error) {
+	reloader, err := newCertReloader(tc.CertFile, tc.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{GetCertificate: reloader.GetCertificate}
+	if tc.ClientCAFile != "" {
+		pem, err := ioutil.ReadFile(tc.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("anoweb: no certificates found in %s", tc.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	if tc.ClientAuth != "" {
+		clientAuth, have := config.ClientAuthTypes[tc.ClientAuth]
+		if !have {
+			return nil, errors.New("anoweb: client auth not supported : " + tc.ClientAuth)
+		}
+		tlsConfig.ClientAuth = clientAuth
+	}
+	if tc.Watch {
+		interval := tc.WatchInterval
+		if interval <= 0 {
+			interval = time.Minute
+		}
+		a.certReloader = reloader
+		go reloader.watch(interval, a.logger)
+	}
+	return tlsConfig, nil
+}
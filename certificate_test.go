@@ -0,0 +1,186 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anoweb
+
+import (
+	stdctx "context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-the-way/anoweb/config"
+	"github.com/go-the-way/anoweb/context"
+	"github.com/stretchr/testify/require"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// newTestCert return a certificate signed by parent, self-signed if parent is nil
+func newTestCert(t *testing.T, cn string, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.Nil(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.Nil(t, err)
+	return &testCert{cert, key}
+}
+
+func (c *testCert) write(t *testing.T, certFile, keyFile string) {
+	keyDer, err := x509.MarshalECPrivateKey(c.key)
+	require.Nil(t, err)
+	require.Nil(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}), 0600))
+	require.Nil(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key, Leaf: c.cert}
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "a.crt"), filepath.Join(dir, "a.key")
+	first := newTestCert(t, "first", nil)
+	first.write(t, certFile, keyFile)
+	c, err := newCertReloader(certFile, keyFile)
+	require.Nil(t, err)
+	go c.watch(time.Millisecond*10, log.New(ioutil.Discard, "", 0))
+	defer c.Stop()
+	cert, _ := c.GetCertificate(nil)
+	require.Equal(t, first.cert.Raw, cert.Certificate[0])
+	// rotate
+	second := newTestCert(t, "second", nil)
+	second.write(t, certFile, keyFile)
+	future := time.Now().Add(time.Minute)
+	_ = os.Chtimes(certFile, future, future)
+	require.Eventually(t, func() bool {
+		cert, _ := c.GetCertificate(nil)
+		return string(cert.Certificate[0]) == string(second.cert.Raw)
+	}, time.Second, time.Millisecond*10)
+	// broken files keep the current certificate
+	require.Nil(t, ioutil.WriteFile(keyFile, []byte("broken"), 0600))
+	future = future.Add(time.Minute)
+	_ = os.Chtimes(keyFile, future, future)
+	time.Sleep(time.Millisecond * 50)
+	cert, _ = c.GetCertificate(nil)
+	require.Equal(t, second.cert.Raw, cert.Certificate[0])
+	c.Stop()
+	c.Stop()
+}
+
+func TestNewCertReloaderError(t *testing.T) {
+	_, err := newCertReloader("none.crt", "none.key")
+	require.NotNil(t, err)
+}
+
+func TestAppNewTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "a.crt"), filepath.Join(dir, "a.key"), filepath.Join(dir, "ca.crt")
+	ca := newTestCert(t, "ca", nil)
+	ca.write(t, caFile, filepath.Join(dir, "ca.key"))
+	newTestCert(t, "server", ca).write(t, certFile, keyFile)
+	test := func(clientCAFile, clientAuth string, expect tls.ClientAuthType, expectErr bool) {
+		a := New()
+		tlsConfig, err := a.newTLSConfig(&config.TLS{CertFile: certFile, KeyFile: keyFile, ClientCAFile: clientCAFile, ClientAuth: clientAuth})
+		if expectErr {
+			require.NotNil(t, err)
+			return
+		}
+		require.Nil(t, err)
+		require.Equal(t, expect, tlsConfig.ClientAuth)
+		require.Equal(t, clientCAFile != "", tlsConfig.ClientCAs != nil)
+	}
+	test("", "", tls.NoClientCert, false)
+	test("", "request", tls.RequestClientCert, false)
+	test(caFile, "", tls.RequireAndVerifyClientCert, false)
+	test(caFile, "verify_if_given", tls.VerifyClientCertIfGiven, false)
+	test(caFile, "unknown", 0, true)
+	test("none.crt", "", 0, true)
+	test(keyFile, "", 0, true)
+}
+
+func TestAppMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "a.crt"), filepath.Join(dir, "a.key"), filepath.Join(dir, "ca.crt")
+	ca := newTestCert(t, "ca", nil)
+	ca.write(t, caFile, filepath.Join(dir, "ca.key"))
+	newTestCert(t, "server", ca).write(t, certFile, keyFile)
+	port := nextPort()
+	testAppEnv(port)
+	_ = os.Setenv(envServerTLSEnable, "true")
+	_ = os.Setenv(envServerTLSCertFile, certFile)
+	_ = os.Setenv(envServerTLSKeyFile, keyFile)
+	_ = os.Setenv(envServerTLSClientCAFile, caFile)
+	_ = os.Setenv(envServerTLSWatch, "true")
+	defer func() {
+		_ = os.Setenv(envServerTLSEnable, "false")
+		_ = os.Unsetenv(envServerTLSClientCAFile)
+		_ = os.Unsetenv(envServerTLSWatch)
+	}()
+	a := New().Get("/", func(ctx *context.Context) { ctx.Text(ctx.ClientSubject().CommonName) })
+	require.Nil(t, a.Start())
+	defer func() { _ = a.Shutdown(stdctx.Background()) }()
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	newClient := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{Timeout: time.Second, Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs},
+		}}
+	}
+	{
+		resp, err := newClient(newTestCert(t, "alice", ca).tlsCertificate()).Get(fmt.Sprintf("https://localhost:%d/", port))
+		require.Nil(t, err)
+		body, _ := ioutil.ReadAll(resp.Body)
+		require.Equal(t, "alice", string(body))
+	}
+	{
+		_, err := newClient().Get(fmt.Sprintf("https://localhost:%d/", port))
+		require.NotNil(t, err)
+	}
+	{
+		_, err := newClient(newTestCert(t, "mallory", nil).tlsCertificate()).Get(fmt.Sprintf("https://localhost:%d/", port))
+		require.NotNil(t, err)
+	}
+}
@@ -12,14 +12,122 @@
 package anoweb
 
 import (
+	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
+	"strings"
+
+	"github.com/go-the-way/anoweb/config"
+	"gopkg.in/yaml.v3"
 )
 
-func (a *App) parseYml() {
+// Profile sets the active configuration profiles, env ANOWEB_PROFILE takes precedence.
+//
+// Each profile overlays app-{profile}.yml onto app.yml in order.
+func (a *App) Profile(profiles ...string) *App {
+	a.profiles = splitProfiles(strings.Join(profiles, ","))
+	return a
+}
+
+// Profiles return the active configuration profiles
+func (a *App) Profiles() []string {
+	return a.profiles
+}
+
+func splitProfiles(profiles string) []string {
+	ps := make([]string, 0)
+	for _, p := range strings.Split(profiles, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			ps = append(ps, p)
+		}
+	}
+	return ps
+}
+
+// configFiles return app.yml followed by the app-{profile}.yml of each active profile
+func (a *App) configFiles() []string {
 	file, _ := filepath.Abs(a.ConfigFile)
-	bytes, err := ioutil.ReadFile(file)
-	if err == nil {
-		_ = a.Config.Unmarshal(bytes)
+	ext := filepath.Ext(file)
+	base := strings.TrimSuffix(file, ext)
+	files := []string{file}
+	for _, p := range a.profiles {
+		files = append(files, fmt.Sprintf("%s-%s%s", base, p, ext))
+	}
+	return files
+}
+
+func (a *App) parseYml() error {
+	a.setProfiles()
+	doc := make(map[string]interface{})
+	for _, file := range a.configFiles() {
+		bytes, err := ioutil.ReadFile(file)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		m := make(map[string]interface{})
+		if err = yaml.Unmarshal(bytes, &m); err != nil {
+			return fmt.Errorf("anoweb: parse %s: %v", file, err)
+		}
+		config.Merge(doc, m)
+	}
+	a.configDoc = doc
+	if len(doc) == 0 {
+		return nil
+	}
+	bytes, err := yaml.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return a.Config.Unmarshal(bytes)
+}
+
+type configBind struct {
+	name string
+	ptr  interface{}
+}
+
+// BindConfig binds the top-level yaml section name into the struct pointed by ptr.
+//
+// Zero fields are defaulted from their `default` tag, then decoded from the section,
+// then overridden by env derived from the key path, e.g. section database key dsn from DATABASE_DSN.
+// Unknown or invalid keys are reported, the binding is applied again when App starts.
+func (a *App) BindConfig(name string, ptr interface{}) error {
+	if a.configDoc == nil {
+		a.setConfigFile()
+		if err := a.parseYml(); err != nil {
+			return err
+		}
+	}
+	bind := &configBind{name, ptr}
+	if err := a.bindConfig(bind); err != nil {
+		return err
+	}
+	a.configBinds = append(a.configBinds, bind)
+	return nil
+}
+
+func (a *App) bindConfig(bind *configBind) error {
+	if err := config.Defaults(bind.ptr); err != nil {
+		return err
+	}
+	if section := a.configDoc[bind.name]; section != nil {
+		if err := config.Decode(section, bind.ptr); err != nil {
+			return fmt.Errorf("anoweb: config section %s: %v", bind.name, err)
+		}
+	}
+	_, err := config.Env(bind.name, bind.ptr, lookupEnv)
+	return err
+}
+
+func (a *App) bindConfigs() error {
+	for _, bind := range a.configBinds {
+		if err := a.bindConfig(bind); err != nil {
+			return err
+		}
 	}
+	return nil
 }
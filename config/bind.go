@@ -0,0 +1,214 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	yamlLineRe   = regexp.MustCompile(`line \d+: `)
+)
+
+// Field a leaf field of a config struct
+type Field struct {
+	// Path yaml key path of the field, e.g. [server tls enable]
+	Path []string
+	// Value field value
+	Value reflect.Value
+	// Tag field tag
+	Tag reflect.StructTag
+}
+
+// Key return the dotted yaml key of the field
+func (f *Field) Key() string {
+	return strings.Join(f.Path, ".")
+}
+
+// EnvName return the env name of the field, e.g. SERVER_TLS_ENABLE
+func (f *Field) EnvName(prefix string) string {
+	name := strings.ToUpper(strings.ReplaceAll(strings.Join(f.Path, "_"), "-", "_"))
+	if prefix != "" {
+		name = strings.ToUpper(strings.ReplaceAll(prefix, "-", "_")) + "_" + name
+	}
+	return name
+}
+
+// Set parses s and sets it into the field
+func (f *Field) Set(s string) error {
+	v := f.Value
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		fl, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(fl)
+	case reflect.Slice:
+		items := make([]string, 0)
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	}
+	return nil
+}
+
+// Fields return the settable leaf fields of the struct pointed by ptr, nil struct pointers are allocated
+func Fields(ptr interface{}) ([]*Field, error) {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("config: a non-nil struct pointer required")
+	}
+	return fields(v.Elem(), nil), nil
+}
+
+func fields(v reflect.Value, path []string) []*Field {
+	fs := make([]*Field, 0)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(sf.Tag.Get("yaml"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(sf.Name)
+		}
+		fieldPath := append(append(make([]string, 0, len(path)+1), path...), name)
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Ptr && fv.Type().Elem().Kind() == reflect.Struct {
+			if fv.IsNil() {
+				fv.Set(reflect.New(fv.Type().Elem()))
+			}
+			fv = fv.Elem()
+		}
+		switch {
+		case fv.Kind() == reflect.Struct:
+			fs = append(fs, fields(fv, fieldPath)...)
+		case supported(fv.Type()):
+			fs = append(fs, &Field{fieldPath, fv, sf.Tag})
+		}
+	}
+	return fs
+}
+
+func supported(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.String
+	}
+	return false
+}
+
+// Defaults sets the zero fields of the struct pointed by ptr from their `default` tag
+func Defaults(ptr interface{}) error {
+	fs, err := Fields(ptr)
+	if err != nil {
+		return err
+	}
+	for _, f := range fs {
+		def, have := f.Tag.Lookup("default")
+		if !have || !f.Value.IsZero() {
+			continue
+		}
+		if err = f.Set(def); err != nil {
+			return fmt.Errorf("config: invalid default of %s: %v", f.Key(), err)
+		}
+	}
+	return nil
+}
+
+// Decode decodes the yaml section into the struct pointed by ptr, unknown keys are rejected
+func Decode(section interface{}, ptr interface{}) error {
+	bs, err := yaml.Marshal(section)
+	if err != nil {
+		return err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(bs))
+	dec.KnownFields(true)
+	if err = dec.Decode(ptr); err != nil {
+		return errors.New(yamlLineRe.ReplaceAllString(err.Error(), ""))
+	}
+	return nil
+}
+
+// Env overrides the fields of the struct pointed by ptr from env, named by Field.EnvName(prefix).
+//
+// It returns the field keys applied mapped to their env names.
+func Env(prefix string, ptr interface{}, lookup func(key string) (string, bool)) (map[string]string, error) {
+	fs, err := Fields(ptr)
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[string]string, 0)
+	for _, f := range fs {
+		name := f.EnvName(prefix)
+		val, have := lookup(name)
+		if !have {
+			continue
+		}
+		if err = f.Set(val); err != nil {
+			return applied, fmt.Errorf("config: invalid value of env %s: %v", name, err)
+		}
+		applied[f.Key()] = name
+	}
+	return applied, nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testBind struct {
+	Name    string        `yaml:"name" default:"anoweb"`
+	Port    int           `yaml:"port" default:"80"`
+	Rate    float64       `yaml:"rate"`
+	Size    uint16        `yaml:"size"`
+	Enable  bool          `yaml:"enable"`
+	Timeout time.Duration `yaml:"timeout" default:"1s"`
+	Hosts   []string      `yaml:"hosts"`
+	Skip    string        `yaml:"-"`
+	Nested  *struct {
+		MaxAge int `yaml:"max-age" default:"5"`
+	} `yaml:"nested"`
+	hidden string
+}
+
+func TestFields(t *testing.T) {
+	fs, err := Fields(&testBind{})
+	require.Nil(t, err)
+	keys := make([]string, 0)
+	for _, f := range fs {
+		keys = append(keys, f.Key())
+	}
+	require.Equal(t, []string{"name", "port", "rate", "size", "enable", "timeout", "hosts", "nested.max-age"}, keys)
+	require.Equal(t, "DB_NESTED_MAX_AGE", fs[7].EnvName("db"))
+	require.Equal(t, "NESTED_MAX_AGE", fs[7].EnvName(""))
+	_, err = Fields(testBind{})
+	require.Error(t, err)
+}
+
+func TestDefaults(t *testing.T) {
+	b := &testBind{Port: 8080}
+	require.Nil(t, Defaults(b))
+	require.Equal(t, "anoweb", b.Name)
+	require.Equal(t, 8080, b.Port)
+	require.Equal(t, time.Second, b.Timeout)
+	require.Equal(t, 5, b.Nested.MaxAge)
+	require.Error(t, Defaults(&struct {
+		Port int `default:"x"`
+	}{}))
+}
+
+func TestDecode(t *testing.T) {
+	b := &testBind{}
+	require.Nil(t, Decode(map[string]interface{}{"name": "a", "hosts": []interface{}{"h1", "h2"}}, b))
+	require.Equal(t, "a", b.Name)
+	require.Equal(t, []string{"h1", "h2"}, b.Hosts)
+	err := Decode(map[string]interface{}{"nam": "a"}, b)
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "line")
+	require.Error(t, Decode(map[string]interface{}{"port": "a"}, b))
+}
+
+func TestEnv(t *testing.T) {
+	env := map[string]string{
+		"APP_NAME":           "env",
+		"APP_PORT":           "81",
+		"APP_RATE":           "0.5",
+		"APP_SIZE":           "3",
+		"APP_ENABLE":         "true",
+		"APP_TIMEOUT":        "2m",
+		"APP_HOSTS":          "a, b,",
+		"APP_NESTED_MAX_AGE": "9",
+	}
+	lookup := func(key string) (string, bool) {
+		v, have := env[key]
+		return v, have
+	}
+	b := &testBind{}
+	applied, err := Env("app", b, lookup)
+	require.Nil(t, err)
+	require.Len(t, applied, 8)
+	require.Equal(t, "APP_NESTED_MAX_AGE", applied["nested.max-age"])
+	require.Equal(t, &testBind{Name: "env", Port: 81, Rate: 0.5, Size: 3, Enable: true, Timeout: 2 * time.Minute, Hosts: []string{"a", "b"}, Nested: b.Nested}, b)
+	require.Equal(t, 9, b.Nested.MaxAge)
+	for _, key := range []string{"APP_PORT", "APP_RATE", "APP_SIZE", "APP_ENABLE", "APP_TIMEOUT"} {
+		env = map[string]string{key: "x"}
+		_, err = Env("app", &testBind{}, lookup)
+		require.Error(t, err)
+	}
+}
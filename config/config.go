@@ -33,14 +33,19 @@ func (c *Config) Unmarshal(bytes []byte) error {
 
 // Server Config Server
 type Server struct {
+	Network           string        `yaml:"network"`
 	Host              string        `yaml:"host"`
 	Port              int           `yaml:"port"`
+	Socket            string        `yaml:"socket"`
+	SocketMode        string        `yaml:"socket_mode"`
 	TLS               *TLS          `yaml:"tls"`
 	MaxHeaderSize     int           `yaml:"max_header_size"`
 	ReadTimeout       time.Duration `yaml:"read_timeout"`
 	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
 	WriteTimeout      time.Duration `yaml:"write_timeout"`
 	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout"`
+	HTTP2             *HTTP2        `yaml:"http2"`
 }
 
 // Banner Config Banner
@@ -49,6 +54,7 @@ type Banner struct {
 	Type   string `yaml:"type"`
 	File   string `yaml:"file"`
 	Text   string `yaml:"text"`
+	Routes bool   `yaml:"routes"`
 }
 
 // Template Config Template
@@ -61,27 +67,68 @@ type Template struct {
 
 // TLS Config TLS
 type TLS struct {
-	Enable   bool   `yaml:"enable"`
-	CertFile string `yaml:"cert_file"`
-	KeyFile  string `yaml:"key_file"`
+	Enable        bool          `yaml:"enable"`
+	CertFile      string        `yaml:"cert_file"`
+	KeyFile       string        `yaml:"key_file"`
+	HTTPPort      int           `yaml:"http_port"`
+	Redirect      bool          `yaml:"redirect"`
+	RedirectCode  int           `yaml:"redirect_code"`
+	HSTS          *HSTS         `yaml:"hsts"`
+	Watch         bool          `yaml:"watch"`
+	WatchInterval time.Duration `yaml:"watch_interval"`
+	ClientCAFile  string        `yaml:"client_ca_file"`
+	ClientAuth    string        `yaml:"client_auth"`
+}
+
+// HSTS Config HSTS
+type HSTS struct {
+	Enable            bool          `yaml:"enable"`
+	MaxAge            time.Duration `yaml:"max_age"`
+	IncludeSubDomains bool          `yaml:"include_sub_domains"`
+	Preload           bool          `yaml:"preload"`
+}
+
+// HTTP2 Config HTTP2, zero values fall back to the http2 package defaults
+type HTTP2 struct {
+	MaxConcurrentStreams         uint32        `yaml:"max_concurrent_streams"`
+	MaxReadFrameSize             uint32        `yaml:"max_read_frame_size"`
+	IdleTimeout                  time.Duration `yaml:"idle_timeout"`
+	MaxUploadBufferPerConnection int32         `yaml:"max_upload_buffer_per_connection"`
+	MaxUploadBufferPerStream     int32         `yaml:"max_upload_buffer_per_stream"`
 }
 
 // Default Config
 func Default() *Config {
 	return &Config{
 		Server: &Server{
-			Host: "0.0.0.0",
-			Port: 9494,
+			Network: "tcp",
+			Host:    "0.0.0.0",
+			Port:    9494,
 			TLS: &TLS{
-				Enable:   false,
-				CertFile: "",
-				KeyFile:  "",
+				Enable:       false,
+				CertFile:     "",
+				KeyFile:      "",
+				HTTPPort:     0,
+				Redirect:     false,
+				RedirectCode: http.StatusMovedPermanently,
+				HSTS: &HSTS{
+					Enable: false,
+					MaxAge: 365 * 24 * time.Hour,
+				},
+				Watch:         false,
+				WatchInterval: time.Minute,
+				ClientCAFile:  "",
+				ClientAuth:    "",
 			},
 			MaxHeaderSize:     http.DefaultMaxHeaderBytes,
 			ReadTimeout:       time.Minute,
 			ReadHeaderTimeout: time.Minute,
 			WriteTimeout:      time.Minute,
 			IdleTimeout:       time.Second,
+			ShutdownTimeout:   30 * time.Second,
+			HTTP2: &HTTP2{
+				MaxConcurrentStreams: 250,
+			},
 		},
 		Banner: &Banner{
 			Enable: true,
@@ -28,18 +28,34 @@ func TestUnmarshal(t *testing.T) {
 func TestDefault(t *testing.T) {
 	c := &Config{
 		Server: &Server{
-			Host: "0.0.0.0",
-			Port: 9494,
+			Network: "tcp",
+			Host:    "0.0.0.0",
+			Port:    9494,
 			TLS: &TLS{
-				Enable:   false,
-				CertFile: "",
-				KeyFile:  "",
+				Enable:       false,
+				CertFile:     "",
+				KeyFile:      "",
+				HTTPPort:     0,
+				Redirect:     false,
+				RedirectCode: http.StatusMovedPermanently,
+				HSTS: &HSTS{
+					Enable: false,
+					MaxAge: 365 * 24 * time.Hour,
+				},
+				Watch:         false,
+				WatchInterval: time.Minute,
+				ClientCAFile:  "",
+				ClientAuth:    "",
 			},
 			MaxHeaderSize:     http.DefaultMaxHeaderBytes,
 			ReadTimeout:       time.Minute,
 			ReadHeaderTimeout: time.Minute,
 			WriteTimeout:      time.Minute,
 			IdleTimeout:       time.Second,
+			ShutdownTimeout:   30 * time.Second,
+			HTTP2: &HTTP2{
+				MaxConcurrentStreams: 250,
+			},
 		},
 		Banner: &Banner{
 			Enable: true,
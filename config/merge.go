@@ -0,0 +1,45 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+// Merge deep-merges src into dst and returns dst.
+// Nested maps are merged key by key, any other value in src replaces the one in dst.
+func Merge(dst, src map[string]interface{}) map[string]interface{} {
+	if dst == nil {
+		dst = make(map[string]interface{}, len(src))
+	}
+	for k, sv := range src {
+		sm, srcIsMap := sv.(map[string]interface{})
+		dm, dstIsMap := dst[k].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			dst[k] = Merge(dm, sm)
+		} else {
+			dst[k] = sv
+		}
+	}
+	return dst
+}
+
+// Lookup return the value at the key path of doc
+func Lookup(doc map[string]interface{}, path ...string) (interface{}, bool) {
+	var v interface{} = doc
+	for _, key := range path {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if v, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
@@ -0,0 +1,52 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge(t *testing.T) {
+	dst := map[string]interface{}{
+		"server": map[string]interface{}{"host": "localhost", "port": 9494, "tls": map[string]interface{}{"enable": false}},
+		"banner": map[string]interface{}{"enable": true},
+		"list":   []interface{}{1, 2},
+	}
+	src := map[string]interface{}{
+		"server": map[string]interface{}{"port": 80, "tls": map[string]interface{}{"cert_file": "a.crt"}},
+		"banner": "off",
+		"list":   []interface{}{3},
+		"extra":  map[string]interface{}{"a": 1},
+	}
+	require.Equal(t, map[string]interface{}{
+		"server": map[string]interface{}{"host": "localhost", "port": 80, "tls": map[string]interface{}{"enable": false, "cert_file": "a.crt"}},
+		"banner": "off",
+		"list":   []interface{}{3},
+		"extra":  map[string]interface{}{"a": 1},
+	}, Merge(dst, src))
+	require.Equal(t, map[string]interface{}{"a": 1}, Merge(nil, map[string]interface{}{"a": 1}))
+}
+
+func TestLookup(t *testing.T) {
+	doc := map[string]interface{}{"server": map[string]interface{}{"tls": map[string]interface{}{"enable": true}}, "banner": "off"}
+	v, have := Lookup(doc, "server", "tls", "enable")
+	require.True(t, have)
+	require.Equal(t, true, v)
+	_, have = Lookup(doc, "server", "port")
+	require.False(t, have)
+	_, have = Lookup(doc, "banner", "type")
+	require.False(t, have)
+	_, have = Lookup(nil, "server")
+	require.False(t, have)
+}
@@ -0,0 +1,144 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ClientAuthTypes the client auth types of server.tls.client_auth
+var ClientAuthTypes = map[string]tls.ClientAuthType{
+	"none":               tls.NoClientCert,
+	"request":            tls.RequestClientCert,
+	"require":            tls.RequireAnyClientCert,
+	"verify_if_given":    tls.VerifyClientCertIfGiven,
+	"require_and_verify": tls.RequireAndVerifyClientCert,
+}
+
+var (
+	networks     = []string{"tcp", "tcp4", "tcp6", "unix"}
+	bannerTypes  = []string{"default", "text", "file"}
+	redirectCode = []int{http.StatusMovedPermanently, http.StatusPermanentRedirect}
+)
+
+// ValidationError the problems found by Config.Validate
+type ValidationError struct {
+	Problems []string
+}
+
+// Error return all problems, one per line
+func (e *ValidationError) Error() string {
+	return "config: invalid configuration:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+// Validate checks the Config, all problems are reported as a *ValidationError
+func (c *Config) Validate() error {
+	problems := make([]string, 0)
+	addf := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	if s := c.Server; s != nil {
+		if !containsString(networks, s.Network) {
+			addf("server.network: unsupported network %q, expected one of %s", s.Network, strings.Join(networks, ", "))
+		}
+		if !validPort(s.Port) {
+			addf("server.port: %d out of range 0-65535", s.Port)
+		}
+		if s.Network == "unix" && s.Socket == "" {
+			addf("server.socket: required when server.network is unix")
+		}
+		if s.SocketMode != "" {
+			if _, err := strconv.ParseUint(s.SocketMode, 8, 32); err != nil {
+				addf("server.socket_mode: %q is not an octal file mode", s.SocketMode)
+			}
+		}
+		if s.MaxHeaderSize < 0 {
+			addf("server.max_header_size: %d must not be negative", s.MaxHeaderSize)
+		}
+		if tls := s.TLS; tls != nil && tls.Enable {
+			if !validPort(tls.HTTPPort) {
+				addf("server.tls.http_port: %d out of range 0-65535", tls.HTTPPort)
+			} else if tls.HTTPPort != 0 && tls.HTTPPort == s.Port {
+				addf("server.tls.http_port: %d conflicts with server.port", tls.HTTPPort)
+			}
+			if tls.Redirect && !containsInt(redirectCode, tls.RedirectCode) {
+				addf("server.tls.redirect_code: %d is not 301 or 308", tls.RedirectCode)
+			}
+			fileProblem(addf, "server.tls.cert_file", tls.CertFile, true)
+			fileProblem(addf, "server.tls.key_file", tls.KeyFile, true)
+			fileProblem(addf, "server.tls.client_ca_file", tls.ClientCAFile, false)
+			if _, have := ClientAuthTypes[tls.ClientAuth]; tls.ClientAuth != "" && !have {
+				addf("server.tls.client_auth: unknown client auth %q, expected one of %s", tls.ClientAuth, strings.Join(clientAuths(), ", "))
+			}
+		}
+	}
+	if b := c.Banner; b != nil && b.Enable {
+		if !containsString(bannerTypes, b.Type) {
+			addf("banner.type: unknown banner type %q, expected one of %s", b.Type, strings.Join(bannerTypes, ", "))
+		} else if b.Type == "file" {
+			fileProblem(addf, "banner.file", b.File, true)
+		}
+	}
+	if len(problems) > 0 {
+		return &ValidationError{problems}
+	}
+	return nil
+}
+
+func clientAuths() []string {
+	auths := make([]string, 0, len(ClientAuthTypes))
+	for auth := range ClientAuthTypes {
+		auths = append(auths, auth)
+	}
+	sort.Strings(auths)
+	return auths
+}
+
+func validPort(port int) bool {
+	return port >= 0 && port <= 65535
+}
+
+func fileProblem(addf func(string, ...interface{}), key, file string, required bool) {
+	if file == "" {
+		if required {
+			addf("%s: required", key)
+		}
+		return
+	}
+	if _, err := os.Stat(file); err != nil {
+		addf("%s: %v", key, err)
+	}
+}
+
+func containsString(ss []string, s string) bool {
+	for _, e := range ss {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(is []int, i int) bool {
+	for _, e := range is {
+		if e == i {
+			return true
+		}
+	}
+	return false
+}
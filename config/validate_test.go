@@ -0,0 +1,72 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	require.Nil(t, Default().Validate())
+
+	file := filepath.Join(t.TempDir(), "a.pem")
+	require.Nil(t, ioutil.WriteFile(file, []byte("pem"), 0600))
+	c := Default()
+	c.Server.TLS.Enable = true
+	c.Server.TLS.CertFile = file
+	c.Server.TLS.KeyFile = file
+	c.Server.TLS.HTTPPort = 80
+	c.Server.TLS.Redirect = true
+	c.Server.TLS.ClientAuth = "verify_if_given"
+	require.Nil(t, c.Validate())
+
+	for _, tc := range []struct {
+		key    string
+		modify func(c *Config)
+	}{
+		{"server.network", func(c *Config) { c.Server.Network = "udp" }},
+		{"server.port", func(c *Config) { c.Server.Port = 65536 }},
+		{"server.port", func(c *Config) { c.Server.Port = -1 }},
+		{"server.socket:", func(c *Config) { c.Server.Network = "unix" }},
+		{"server.socket_mode", func(c *Config) { c.Server.SocketMode = "0x1" }},
+		{"server.max_header_size", func(c *Config) { c.Server.MaxHeaderSize = -1 }},
+		{"server.tls.http_port", func(c *Config) { c.Server.TLS.HTTPPort = 70000 }},
+		{"server.tls.http_port", func(c *Config) { c.Server.TLS.HTTPPort = c.Server.Port }},
+		{"server.tls.redirect_code", func(c *Config) { c.Server.TLS.RedirectCode = 302 }},
+		{"server.tls.cert_file: required", func(c *Config) { c.Server.TLS.CertFile = "" }},
+		{"server.tls.key_file", func(c *Config) { c.Server.TLS.KeyFile = "missing.key" }},
+		{"server.tls.client_ca_file", func(c *Config) { c.Server.TLS.ClientCAFile = "missing.pem" }},
+		{"server.tls.client_auth: unknown client auth \"verify\"", func(c *Config) { c.Server.TLS.ClientAuth = "verify" }},
+		{"banner.type", func(c *Config) { c.Banner.Type = "fancy" }},
+		{"banner.file", func(c *Config) { c.Banner.Type = "file"; c.Banner.File = "missing.txt" }},
+	} {
+		c := Default()
+		c.Server.TLS.Enable = true
+		c.Server.TLS.CertFile = file
+		c.Server.TLS.KeyFile = file
+		c.Server.TLS.Redirect = true
+		tc.modify(c)
+		err := c.Validate()
+		require.Error(t, err, tc.key)
+		require.IsType(t, &ValidationError{}, err)
+		require.Contains(t, err.Error(), tc.key)
+	}
+
+	c = Default()
+	c.Server.Port = 65536
+	c.Banner.Type = "fancy"
+	require.Len(t, c.Validate().(*ValidationError).Problems, 2)
+}
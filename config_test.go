@@ -13,12 +13,18 @@ package anoweb
 
 import (
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"gopkg.in/yaml.v3"
 
 	"github.com/go-the-way/anoweb/config"
+	"github.com/go-the-way/anoweb/context"
 
 	"github.com/stretchr/testify/require"
 )
@@ -42,3 +48,151 @@ func TestConfig(t *testing.T) {
 	a.parseYml()
 	require.Equal(t, cfg, a.Config)
 }
+
+func TestConfigProfiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		require.Nil(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+	write("app.yml", "server:\n  host: localhost\n  port: 8000\n  tls:\n    cert_file: base.crt\nbanner:\n  text: base\n")
+	write("app-prod.yml", "server:\n  port: 8001\n  tls:\n    key_file: prod.key\n")
+	write("app-eu.yml", "banner:\n  text: eu\n")
+	_ = os.Setenv(envProfile, "prod, eu")
+	_ = os.Setenv(envServerPort, "8002")
+	defer func() {
+		_ = os.Unsetenv(envProfile)
+		_ = os.Unsetenv(envServerPort)
+	}()
+	a := New()
+	a.ConfigFile = filepath.Join(dir, "app.yml")
+	require.Nil(t, a.parseYml())
+	require.Equal(t, []string{"prod", "eu"}, a.Profiles())
+	require.Equal(t, "localhost", a.Config.Server.Host)
+	require.Equal(t, 8001, a.Config.Server.Port)
+	require.Equal(t, "base.crt", a.Config.Server.TLS.CertFile)
+	require.Equal(t, "prod.key", a.Config.Server.TLS.KeyFile)
+	require.Equal(t, "eu", a.Config.Banner.Text)
+	require.Equal(t, "default", a.Config.Banner.Type)
+	require.Nil(t, a.parseEnv())
+	require.Equal(t, 8002, a.Config.Server.Port)
+}
+
+func TestConfigProfile(t *testing.T) {
+	a := New().Profile("dev", " local,test")
+	require.Equal(t, []string{"dev", "local", "test"}, a.Profiles())
+	a.ConfigFile = "conf/app.yaml"
+	files := a.configFiles()
+	require.Len(t, files, 4)
+	require.True(t, strings.HasSuffix(files[0], filepath.Join("conf", "app.yaml")))
+	require.True(t, strings.HasSuffix(files[3], filepath.Join("conf", "app-test.yaml")))
+	require.Nil(t, a.parseYml())
+}
+
+func TestConfigInvalid(t *testing.T) {
+	dir := t.TempDir()
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, "app.yml"), []byte("server: ["), 0600))
+	a := New()
+	a.ConfigFile = filepath.Join(dir, "app.yml")
+	require.NotNil(t, a.parseYml())
+}
+
+func TestConfigProfilesHandler(t *testing.T) {
+	var profiles []string
+	a := New().Profile("prod").Get("/", func(ctx *context.Context) {
+		profiles = ctx.Profiles()
+	}).parseRouters()
+	a.newDispatcher().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, []string{"prod"}, profiles)
+}
+
+type testDatabaseConfig struct {
+	DSN     string        `yaml:"dsn"`
+	MaxOpen int           `yaml:"max_open" default:"10"`
+	Timeout time.Duration `yaml:"timeout" default:"5s"`
+	Debug   bool          `yaml:"debug"`
+	Pool    struct {
+		Size int `yaml:"size" default:"2"`
+	} `yaml:"pool"`
+}
+
+func TestBindConfig(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.yml")
+	require.Nil(t, ioutil.WriteFile(file, []byte("database:\n  dsn: mysql://yaml\n  debug: true\n  pool:\n    size: 4\n"), 0600))
+	_ = os.Setenv("DATABASE_DSN", "mysql://env")
+	defer func() { _ = os.Unsetenv("DATABASE_DSN") }()
+	a := New()
+	a.ConfigFile = file
+	var db testDatabaseConfig
+	require.Nil(t, a.BindConfig("database", &db))
+	require.Equal(t, "mysql://env", db.DSN)
+	require.Equal(t, 10, db.MaxOpen)
+	require.Equal(t, 5*time.Second, db.Timeout)
+	require.True(t, db.Debug)
+	require.Equal(t, 4, db.Pool.Size)
+	require.Len(t, a.configBinds, 1)
+
+	_ = os.Setenv(envPrefix+"DATABASE_DSN", "mysql://prefixed")
+	_ = os.Setenv("DATABASE_DEBUG", "")
+	defer func() { _ = os.Unsetenv(envPrefix + "DATABASE_DSN"); _ = os.Unsetenv("DATABASE_DEBUG") }()
+	require.Nil(t, a.bindConfigs())
+	require.Equal(t, "mysql://prefixed", db.DSN)
+	require.True(t, db.Debug)
+}
+
+func TestBindConfigMissing(t *testing.T) {
+	a := New()
+	a.ConfigFile = filepath.Join(t.TempDir(), "app.yml")
+	var db testDatabaseConfig
+	require.Nil(t, a.BindConfig("database", &db))
+	require.Equal(t, 10, db.MaxOpen)
+	require.Equal(t, 2, db.Pool.Size)
+}
+
+func TestBindConfigError(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		file := filepath.Join(dir, "app.yml")
+		require.Nil(t, ioutil.WriteFile(file, []byte(content), 0600))
+		return file
+	}
+	{
+		a := New()
+		a.ConfigFile = write("database:\n  dsnn: mysql://yaml\n")
+		err := a.BindConfig("database", &testDatabaseConfig{})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "dsnn")
+	}
+	{
+		a := New()
+		a.ConfigFile = write("database:\n  max_open: many\n")
+		require.Error(t, a.BindConfig("database", &testDatabaseConfig{}))
+	}
+	{
+		_ = os.Setenv("DATABASE_MAX_OPEN", "many")
+		a := New()
+		a.ConfigFile = write("")
+		err := a.BindConfig("database", &testDatabaseConfig{})
+		_ = os.Unsetenv("DATABASE_MAX_OPEN")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "DATABASE_MAX_OPEN")
+	}
+	{
+		a := New()
+		a.ConfigFile = write("")
+		require.Error(t, a.BindConfig("database", testDatabaseConfig{}))
+	}
+}
+
+func TestBindConfigStart(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.yml")
+	require.Nil(t, ioutil.WriteFile(file, []byte("database:\n  dsn: mysql://yaml\n"), 0600))
+	a := New()
+	a.ConfigFile = file
+	var db testDatabaseConfig
+	require.Nil(t, a.BindConfig("database", &db))
+	require.Nil(t, ioutil.WriteFile(file, []byte("database:\n  unknown: 1\n"), 0600))
+	require.Nil(t, a.parseYml())
+	require.Error(t, a.bindConfigs())
+}
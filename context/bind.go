@@ -14,13 +14,21 @@ package context
 import (
 	"encoding/json"
 	"io/ioutil"
+	"net/http"
 )
 
-// Bind struct ptr
+// Bind struct ptr, panics the error of BindE
 func (ctx *Context) Bind(structPtr interface{}) {
-	readAll, _ := ioutil.ReadAll(ctx.Request.Body)
-	err := json.Unmarshal(readAll, structPtr)
-	if err != nil {
+	if err := ctx.BindE(structPtr); err != nil {
 		panic(err)
 	}
 }
+
+// BindE struct ptr, return 400 HTTPError if the body is invalid
+func (ctx *Context) BindE(structPtr interface{}) error {
+	readAll, _ := ioutil.ReadAll(ctx.Request.Body)
+	if err := json.Unmarshal(readAll, structPtr); err != nil {
+		return NewHTTPError(http.StatusBadRequest, "Invalid request body").Wrap(err)
+	}
+	return nil
+}
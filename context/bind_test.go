@@ -13,6 +13,7 @@ package context
 
 import (
 	"bytes"
+	"errors"
 	"github.com/go-the-way/anoweb/config"
 	"github.com/stretchr/testify/require"
 	"net/http"
@@ -54,3 +55,15 @@ func TestContextBindPanic(t *testing.T) {
 	ctx.Allocate(buildReq(`<xml></xml>`), &config.Template{})
 	ctx.Bind(&m)
 }
+
+func TestContextBindE(t *testing.T) {
+	var m map[string]interface{}
+	ctx := New()
+	ctx.Allocate(buildReq(`{"id":1}`), &config.Template{})
+	require.Nil(t, ctx.BindE(&m))
+	require.Equal(t, float64(1), m["id"])
+	ctx.Allocate(buildReq(`<xml></xml>`), &config.Template{})
+	err := ctx.BindE(&m)
+	require.Equal(t, http.StatusBadRequest, err.(*HTTPError).Status)
+	require.NotNil(t, errors.Unwrap(err))
+}
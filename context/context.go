@@ -29,27 +29,85 @@ type Context struct {
 	dataMap        map[string]interface{}
 	funcMap        template.FuncMap
 	templateConfig *config.Template
+	profiles       []string
+	urlFunc        func(name string, params ...interface{}) (string, error)
+	writer         *ResponseWriter
+	err            error
+	errHandled     bool
+	errorHandler   func(ctx *Context, err error)
+	aborted        bool
+	destroyed      bool
 }
 
 // New context
 func New() *Context {
-	ctx := &Context{
-		Response:     Builder().DefaultBuild(),
-		handlers:     make([]func(c *Context), 0),
-		paramMap:     make(map[string][]string, 0),
-		MultipartMap: make(map[string][]*MultipartFile, 0),
-		dataMap:      make(map[string]interface{}, 0),
+	ctx := &Context{}
+	ctx.Reset()
+	return ctx
+}
+
+// Reset clears the context for the next request and calls the created listeners,
+// the dispatcher resets the pooled contexts, so a context must not be used after its request is done
+func (ctx *Context) Reset() {
+	ctx.Request = nil
+	ctx.Response = Builder().DefaultBuild()
+	for i := range ctx.handlers {
+		ctx.handlers[i] = nil
+	}
+	if ctx.handlers == nil {
+		ctx.handlers = make([]func(c *Context), 0)
+	}
+	ctx.handlers = ctx.handlers[:0]
+	ctx.pos = 0
+	if ctx.paramMap == nil {
+		ctx.paramMap = make(map[string][]string, 0)
+	}
+	for k := range ctx.paramMap {
+		delete(ctx.paramMap, k)
+	}
+	if ctx.MultipartMap == nil {
+		ctx.MultipartMap = make(map[string][]*MultipartFile, 0)
+	}
+	for k := range ctx.MultipartMap {
+		delete(ctx.MultipartMap, k)
 	}
+	if ctx.dataMap == nil {
+		ctx.dataMap = make(map[string]interface{}, 0)
+	}
+	for k := range ctx.dataMap {
+		delete(ctx.dataMap, k)
+	}
+	if ctx.funcMap == nil {
+		ctx.funcMap = make(template.FuncMap, 0)
+	}
+	for k := range ctx.funcMap {
+		delete(ctx.funcMap, k)
+	}
+	ctx.templateConfig = nil
+	ctx.profiles = nil
+	ctx.urlFunc = nil
+	ctx.writer = nil
+	ctx.err = nil
+	ctx.errHandled = false
+	ctx.errorHandler = nil
+	ctx.aborted = false
+	ctx.destroyed = false
 	ctx.onCreated()
-	return ctx
+}
+
+// Destroy calls the destroyed listeners once, the dispatcher calls it when the request is done, even on panic
+func (ctx *Context) Destroy() {
+	if !ctx.destroyed {
+		ctx.destroyed = true
+		ctx.onDestroyed()
+	}
 }
 
 func (ctx *Context) Allocate(req *http.Request, templateConfig *config.Template) {
 	ctx.Request = req
-	funcMap := make(map[string]interface{}, 0)
 	if templateConfig != nil && templateConfig.FuncMap != nil {
 		for k, v := range templateConfig.FuncMap {
-			funcMap[k] = v
+			ctx.funcMap[k] = v
 		}
 	}
 	_ = ctx.Request.ParseForm()
@@ -59,7 +117,6 @@ func (ctx *Context) Allocate(req *http.Request, templateConfig *config.Template)
 		}
 	}
 	ctx.templateConfig = templateConfig
-	ctx.funcMap = funcMap
 }
 
 // Add context handler
@@ -74,16 +131,54 @@ func (ctx *Context) Add(handlers ...func(ctx *Context)) *Context {
 
 // Chain execute context handler
 func (ctx *Context) Chain() {
+	if ctx.aborted {
+		return
+	}
 	if len(ctx.handlers) > 0 {
 		if ctx.pos > len(ctx.handlers)-1 {
-			ctx.onDestroyed()
+			ctx.Destroy()
 			return
 		}
 		ctx.pos++
 		ctx.handlers[ctx.pos-1](ctx)
+		ctx.handleError()
 	}
 }
 
+// Next execute the next handler, same as Chain, the code after Next runs when the inner handlers return
+func (ctx *Context) Next() {
+	ctx.Chain()
+}
+
+// Abort stops the remaining handlers, the outer handlers still run the code after their Next
+func (ctx *Context) Abort() {
+	ctx.aborted = true
+}
+
+// AbortWithStatus aborts with the status
+func (ctx *Context) AbortWithStatus(status int) {
+	ctx.Abort()
+	ctx.Status(status)
+}
+
+// AbortWithStatusJSON aborts with the status and the JSON data
+func (ctx *Context) AbortWithStatusJSON(status int, data interface{}) {
+	ctx.Abort()
+	ctx.JSON(data)
+	ctx.Status(status)
+}
+
+// AbortWithError aborts with the error responded by the error handler
+func (ctx *Context) AbortWithError(err error) {
+	ctx.Abort()
+	ctx.SetError(err)
+}
+
+// IsAborted reports whether the handlers are aborted
+func (ctx *Context) IsAborted() bool {
+	return ctx.aborted
+}
+
 // Data buffer
 func (ctx *Context) Data(data []byte) *Context {
 	ctx.Response.Data = data
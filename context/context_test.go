@@ -12,6 +12,7 @@
 package context
 
 import (
+	"fmt"
 	"github.com/go-the-way/anoweb/config"
 	"github.com/stretchr/testify/require"
 	"html/template"
@@ -86,3 +87,123 @@ func TestContextAddCookie(t *testing.T) {
 	ctx.AddCookie(&http.Cookie{Name: "apple", Value: "100"})
 	require.Equal(t, []*http.Cookie{{Name: "apple", Value: "100"}}, ctx.Response.Cookies)
 }
+
+func TestContextAbort(t *testing.T) {
+	var trace []string
+	mw := func(name string) func(ctx *Context) {
+		return func(ctx *Context) {
+			trace = append(trace, name+" before")
+			ctx.Next()
+			trace = append(trace, fmt.Sprintf("%s after %v", name, ctx.IsAborted()))
+		}
+	}
+	auth := func(ctx *Context) {
+		if ctx.Param("token") == "" {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		}
+	}
+	ctx := New()
+	ctx.Allocate(buildReq(""), &config.Template{})
+	ctx.Add(mw("outer"), mw("inner"), func(ctx *Context) {
+		auth(ctx)
+		ctx.Next()
+		trace = append(trace, "guard after")
+	}, func(ctx *Context) {
+		trace = append(trace, "handler")
+	})
+	ctx.Chain()
+	require.True(t, ctx.IsAborted())
+	require.Equal(t, []string{"outer before", "inner before", "guard after", "inner after true", "outer after true"}, trace)
+	require.Equal(t, http.StatusUnauthorized, ctx.Response.Status)
+	require.Equal(t, `{"error":"unauthorized"}`, string(ctx.Response.Data))
+
+	trace = nil
+	ctx = New()
+	ctx.Allocate(buildReq(""), &config.Template{})
+	ctx.SetParamMap(map[string][]string{"token": {"1"}}, false)
+	ctx.Add(mw("outer"), func(ctx *Context) {
+		auth(ctx)
+		ctx.Next()
+	}, func(ctx *Context) {
+		trace = append(trace, "handler")
+	})
+	ctx.Chain()
+	require.False(t, ctx.IsAborted())
+	require.Equal(t, []string{"outer before", "handler", "outer after false"}, trace)
+}
+
+func TestContextAbortWith(t *testing.T) {
+	ctx := New()
+	ctx.AbortWithStatus(http.StatusForbidden)
+	require.True(t, ctx.IsAborted())
+	require.Equal(t, http.StatusForbidden, ctx.Response.Status)
+	ctx.Add(func(ctx *Context) { panic("must not run") })
+	ctx.Chain()
+
+	err := NewHTTPError(http.StatusConflict)
+	ctx = New()
+	ctx.AbortWithError(err)
+	require.True(t, ctx.IsAborted())
+	require.Equal(t, err, ctx.LastError())
+}
+
+func TestContextReset(t *testing.T) {
+	var created, destroyed int
+	ClearListeners()
+	AddListeners(&Listener{Created: func(ctx *Context) { created++ }, Destroyed: func(ctx *Context) { destroyed++ }})
+	defer ClearListeners()
+	ctx := New()
+	ctx.Allocate(buildReq(""), &config.Template{FuncMap: template.FuncMap{"sum": func(a, b int) int { return a + b }}})
+	ctx.paramMap["id"] = []string{"10"}
+	ctx.MultipartMap["file"] = []*MultipartFile{{}}
+	ctx.SetData("name", "apple")
+	ctx.SetProfiles([]string{"dev"})
+	ctx.Add(func(ctx *Context) { ctx.Chain() })
+	ctx.Chain()
+	ctx.Status(http.StatusTeapot).AddCookie(&http.Cookie{Name: "a"})
+	_ = ctx.Writer()
+	ctx.Abort()
+	ctx.Destroy()
+	ctx.Destroy()
+	require.Equal(t, 1, destroyed)
+
+	ctx.Reset()
+	require.Equal(t, 2, created)
+	require.Nil(t, ctx.Request)
+	require.Equal(t, Builder().DefaultBuild(), ctx.Response)
+	require.Empty(t, ctx.handlers)
+	require.Equal(t, 0, ctx.pos)
+	require.Empty(t, ctx.paramMap)
+	require.Empty(t, ctx.MultipartMap)
+	require.Empty(t, ctx.dataMap)
+	require.Empty(t, ctx.funcMap)
+	require.Nil(t, ctx.templateConfig)
+	require.Nil(t, ctx.profiles)
+	require.Nil(t, ctx.writer)
+	require.False(t, ctx.IsAborted())
+	ctx.Destroy()
+	require.Equal(t, 2, destroyed)
+}
+
+func BenchmarkContextNew(b *testing.B) {
+	req := buildReq("")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ctx := New()
+		ctx.Allocate(req, &config.Template{})
+		ctx.Add(func(ctx *Context) {})
+		ctx.Chain()
+	}
+}
+
+func BenchmarkContextReset(b *testing.B) {
+	req := buildReq("")
+	ctx := New()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ctx.Reset()
+		ctx.Allocate(req, &config.Template{})
+		ctx.Add(func(ctx *Context) {})
+		ctx.Chain()
+	}
+}
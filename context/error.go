@@ -0,0 +1,184 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"runtime"
+	"strings"
+
+	"github.com/go-the-way/anoweb/headers"
+	"github.com/go-the-way/anoweb/mime"
+)
+
+// HTTPError defines the error responded with the status
+type HTTPError struct {
+	// Status response status
+	Status int `json:"status"`
+	// Code application error code
+	Code string `json:"code,omitempty"`
+	// Message client message
+	Message string `json:"message"`
+	// Details e.g. the invalid fields
+	Details interface{} `json:"details,omitempty"`
+	// Err the cause, not responded
+	Err error `json:"-"`
+}
+
+// NewHTTPError return new HTTPError, the message defaults to the status text
+func NewHTTPError(status int, message ...string) *HTTPError {
+	msg := http.StatusText(status)
+	if len(message) > 0 && message[0] != "" {
+		msg = message[0]
+	}
+	return &HTTPError{Status: status, Message: msg}
+}
+
+// WithCode sets the code
+func (e *HTTPError) WithCode(code string) *HTTPError {
+	e.Code = code
+	return e
+}
+
+// WithDetails sets the details
+func (e *HTTPError) WithDetails(details interface{}) *HTTPError {
+	e.Details = details
+	return e
+}
+
+// Wrap sets the cause
+func (e *HTTPError) Wrap(err error) *HTTPError {
+	e.Err = err
+	return e
+}
+
+// Error implements error
+func (e *HTTPError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%d %s: %v", e.Status, e.Message, e.Err)
+	}
+	return fmt.Sprintf("%d %s", e.Status, e.Message)
+}
+
+// Unwrap return the cause
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// AsHTTPError return the HTTPError in err's chain, or 500 wrapping err, the message of err is not responded
+func AsHTTPError(err error) *HTTPError {
+	var he *HTTPError
+	if errors.As(err, &he) {
+		return he
+	}
+	return NewHTTPError(http.StatusInternalServerError).Wrap(err)
+}
+
+// Handle adapts the handler returning error, the error is set by SetError,
+// the panics of error values, e.g. Bind or JSON, are set also, the runtime errors are re-panicked
+func Handle(handler func(ctx *Context) error) func(ctx *Context) {
+	return func(ctx *Context) {
+		defer func() {
+			if re := recover(); re != nil {
+				err, ok := re.(error)
+				if _, isRuntime := re.(runtime.Error); !ok || isRuntime {
+					panic(re)
+				}
+				ctx.SetError(err)
+			}
+		}()
+		if err := handler(ctx); err != nil {
+			ctx.SetError(err)
+		}
+	}
+}
+
+// SetError sets the error, which is responded by the error handler once the handler setting it returns,
+// before the outer middlewares resume
+func (ctx *Context) SetError(err error) *Context {
+	ctx.err = err
+	ctx.errHandled = false
+	return ctx
+}
+
+// SetErrorHandler sets the handler responding the errors set by SetError
+func (ctx *Context) SetErrorHandler(handler func(ctx *Context, err error)) *Context {
+	ctx.errorHandler = handler
+	return ctx
+}
+
+// handleError responds the error not responded yet, unless the response is committed
+func (ctx *Context) handleError() {
+	if ctx.err == nil || ctx.errHandled || ctx.errorHandler == nil || ctx.Committed() {
+		return
+	}
+	ctx.errHandled = true
+	ctx.errorHandler(ctx, ctx.err)
+}
+
+// LastError return the error set by SetError
+func (ctx *Context) LastError() error {
+	return ctx.err
+}
+
+// DefaultErrorHandler responds the error as problem+json, HTML or JSON by the Accept header
+func DefaultErrorHandler(ctx *Context, err error) {
+	accept := ""
+	if ctx.Request != nil {
+		accept = ctx.Request.Header.Get(headers.Accept)
+	}
+	he := AsHTTPError(err)
+	switch {
+	case strings.Contains(accept, "application/problem+json"):
+		ctx.ErrorProblem(he)
+	case strings.Contains(accept, "text/html"):
+		ctx.ErrorHTML(he)
+	default:
+		ctx.ErrorJSON(he)
+	}
+}
+
+// ErrorJSON responds the error as JSON
+func (ctx *Context) ErrorJSON(he *HTTPError) {
+	data, _ := json.Marshal(he)
+	ctx.Write(Builder().Data(data).ContentType(mime.JSON).Status(he.Status).Build())
+}
+
+// ErrorProblem responds the error as problem+json of RFC 7807
+func (ctx *Context) ErrorProblem(he *HTTPError) {
+	problem := map[string]interface{}{
+		"type":   "about:blank",
+		"title":  http.StatusText(he.Status),
+		"status": he.Status,
+		"detail": he.Message,
+	}
+	if he.Code != "" {
+		problem["code"] = he.Code
+	}
+	if he.Details != nil {
+		problem["details"] = he.Details
+	}
+	data, _ := json.Marshal(problem)
+	ctx.Write(Builder().Data(data).ContentType(mime.ProblemJSON).Status(he.Status).Build())
+}
+
+// ErrorHTML responds the error as the HTML page
+func (ctx *Context) ErrorHTML(he *HTTPError) {
+	title := html.EscapeString(fmt.Sprintf("%d %s", he.Status, http.StatusText(he.Status)))
+	page := fmt.Sprintf("<!DOCTYPE html>\n<html>\n<head><title>%s</title></head>\n<body>\n<h1>%s</h1>\n<p>%s</p>\n</body>\n</html>\n",
+		title, title, html.EscapeString(he.Message))
+	ctx.Write(Builder().Data([]byte(page)).ContentType(mime.HTML).Status(he.Status).Build())
+}
@@ -0,0 +1,121 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-the-way/anoweb/mime"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPError(t *testing.T) {
+	cause := errors.New("cause")
+	he := NewHTTPError(http.StatusNotFound)
+	require.Equal(t, "Not Found", he.Message)
+	require.Equal(t, "404 Not Found", he.Error())
+	he = NewHTTPError(http.StatusBadRequest, "invalid name").WithCode("E100").WithDetails([]string{"name"}).Wrap(cause)
+	require.Equal(t, &HTTPError{http.StatusBadRequest, "E100", "invalid name", []string{"name"}, cause}, he)
+	require.Equal(t, "400 invalid name: cause", he.Error())
+	require.True(t, errors.Is(he, cause))
+}
+
+func TestAsHTTPError(t *testing.T) {
+	he := NewHTTPError(http.StatusConflict)
+	require.Equal(t, he, AsHTTPError(fmt.Errorf("wrapped: %w", he)))
+	cause := errors.New("db is down")
+	he = AsHTTPError(cause)
+	require.Equal(t, http.StatusInternalServerError, he.Status)
+	require.Equal(t, "Internal Server Error", he.Message)
+	require.Equal(t, cause, he.Err)
+}
+
+func TestHandle(t *testing.T) {
+	ctx := New()
+	ctx.Allocate(httptest.NewRequest(http.MethodGet, "/", nil), nil)
+	Handle(func(ctx *Context) error { return nil })(ctx)
+	require.Nil(t, ctx.LastError())
+	err := errors.New("failed")
+	Handle(func(ctx *Context) error { return err })(ctx)
+	require.Equal(t, err, ctx.LastError())
+
+	ctx = New()
+	ctx.Allocate(buildReq("<xml>"), nil)
+	Handle(func(ctx *Context) error {
+		var v map[string]interface{}
+		ctx.Bind(&v)
+		return nil
+	})(ctx)
+	require.Equal(t, http.StatusBadRequest, AsHTTPError(ctx.LastError()).Status)
+
+	require.PanicsWithValue(t, "not an error", func() {
+		Handle(func(ctx *Context) error { panic("not an error") })(ctx)
+	})
+	require.Panics(t, func() {
+		Handle(func(ctx *Context) error {
+			var values []int
+			return fmt.Errorf("%d", values[1])
+		})(ctx)
+	})
+	ctx.Reset()
+	require.Nil(t, ctx.LastError())
+}
+
+func TestErrorHandledInChain(t *testing.T) {
+	var statuses []int
+	handled := 0
+	ctx := New()
+	ctx.Allocate(httptest.NewRequest(http.MethodGet, "/", nil), nil)
+	ctx.SetErrorHandler(func(ctx *Context, err error) {
+		handled++
+		DefaultErrorHandler(ctx, err)
+	})
+	ctx.Add(func(ctx *Context) {
+		ctx.Next()
+		statuses = append(statuses, ctx.Response.Status)
+	})
+	ctx.Add(Handle(func(ctx *Context) error { return NewHTTPError(http.StatusConflict) }))
+	ctx.Chain()
+	require.Equal(t, []int{http.StatusConflict}, statuses)
+	require.Equal(t, 1, handled)
+}
+
+func TestDefaultErrorHandler(t *testing.T) {
+	he := NewHTTPError(http.StatusBadRequest, "<invalid>").WithCode("E100")
+	for _, tc := range []struct {
+		accept      string
+		contentType string
+		body        string
+	}{
+		{"", mime.JSON, `{"status":400,"code":"E100","message":"\u003cinvalid\u003e"}`},
+		{"application/json", mime.JSON, `{"status":400,"code":"E100","message":"\u003cinvalid\u003e"}`},
+		{"application/problem+json", mime.ProblemJSON, `{"code":"E100","detail":"\u003cinvalid\u003e","status":400,"title":"Bad Request","type":"about:blank"}`},
+		{"text/html,application/xhtml+xml", mime.HTML, "<!DOCTYPE html>\n<html>\n<head><title>400 Bad Request</title></head>\n<body>\n<h1>400 Bad Request</h1>\n<p>&lt;invalid&gt;</p>\n</body>\n</html>\n"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", tc.accept)
+		ctx := New()
+		ctx.Allocate(req, nil)
+		DefaultErrorHandler(ctx, fmt.Errorf("wrapped: %w", he))
+		require.Equal(t, http.StatusBadRequest, ctx.Response.Status, tc.accept)
+		require.Equal(t, tc.contentType, ctx.Response.ContentType, tc.accept)
+		require.Equal(t, tc.body, string(ctx.Response.Data), tc.accept)
+	}
+	ctx := New()
+	DefaultErrorHandler(ctx, NewHTTPError(http.StatusTeapot).WithDetails(map[string]string{"a": "b"}))
+	require.Equal(t, `{"status":418,"message":"I'm a teapot","details":{"a":"b"}}`, string(ctx.Response.Data))
+}
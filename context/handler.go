@@ -0,0 +1,59 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/go-the-way/anoweb/headers"
+)
+
+// WrapHandler adapts the std http.Handler to a context handler.
+//
+// The handler writes into the Response, so it runs under the middleware chain as any other route.
+func WrapHandler(h http.Handler) func(ctx *Context) {
+	return func(ctx *Context) {
+		w := &handlerWriter{header: ctx.Response.Header, status: http.StatusOK}
+		h.ServeHTTP(w, ctx.Request)
+		if contentType := w.header.Get(headers.MIME); contentType != "" {
+			ctx.Response.ContentType = contentType
+			w.header.Del(headers.MIME)
+		} else if w.buf.Len() > 0 {
+			ctx.Response.ContentType = http.DetectContentType(w.buf.Bytes())
+		}
+		ctx.Status(w.status).Data(w.buf.Bytes())
+	}
+}
+
+type handlerWriter struct {
+	header      http.Header
+	buf         bytes.Buffer
+	status      int
+	wroteHeader bool
+}
+
+func (w *handlerWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *handlerWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+}
+
+func (w *handlerWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.buf.Write(b)
+}
@@ -0,0 +1,56 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-the-way/anoweb/mime"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrapHandler(t *testing.T) {
+	{
+		ctx := New()
+		ctx.Allocate(httptest.NewRequest(http.MethodGet, "/a", nil), nil)
+		WrapHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", mime.JSON)
+			w.Header().Set("X-Path", r.URL.Path)
+			w.WriteHeader(http.StatusCreated)
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte("{}"))
+		}))(ctx)
+		require.Equal(t, http.StatusCreated, ctx.Response.Status)
+		require.Equal(t, mime.JSON, ctx.Response.ContentType)
+		require.Equal(t, "/a", ctx.Response.Header.Get("X-Path"))
+		require.Empty(t, ctx.Response.Header.Get("Content-Type"))
+		require.Equal(t, "{}", string(ctx.Response.Data))
+	}
+	{
+		ctx := New()
+		ctx.Allocate(httptest.NewRequest(http.MethodGet, "/a", nil), nil)
+		WrapHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("<html></html>"))
+		}))(ctx)
+		require.Equal(t, http.StatusOK, ctx.Response.Status)
+		require.Equal(t, "text/html; charset=utf-8", ctx.Response.ContentType)
+	}
+	{
+		ctx := New()
+		ctx.Allocate(httptest.NewRequest(http.MethodGet, "/a", nil), nil)
+		WrapHandler(http.NotFoundHandler())(ctx)
+		require.Equal(t, http.StatusNotFound, ctx.Response.Status)
+	}
+}
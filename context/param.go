@@ -13,6 +13,7 @@ package context
 
 import (
 	"fmt"
+	"net/http"
 	"strconv"
 	"strings"
 )
@@ -74,6 +75,49 @@ func (ctx *Context) FloatParam(name string, defaultVal float64) float64 {
 	return floatVal
 }
 
+// IntParamE return named param of int, 400 HTTPError if the param is missing or not int
+func (ctx *Context) IntParamE(name string) (int64, error) {
+	intVal, err := strconv.ParseInt(ctx.Param(name), 10, 64)
+	if err != nil {
+		return 0, ctx.paramError(name, err)
+	}
+	return intVal, nil
+}
+
+// UintParamE return named param of uint, 400 HTTPError if the param is missing or not uint
+func (ctx *Context) UintParamE(name string) (uint64, error) {
+	uintVal, err := strconv.ParseUint(ctx.Param(name), 10, 64)
+	if err != nil {
+		return 0, ctx.paramError(name, err)
+	}
+	return uintVal, nil
+}
+
+// FloatParamE return named param of float, 400 HTTPError if the param is missing or not float
+func (ctx *Context) FloatParamE(name string) (float64, error) {
+	floatVal, err := strconv.ParseFloat(ctx.Param(name), 64)
+	if err != nil {
+		return 0, ctx.paramError(name, err)
+	}
+	return floatVal, nil
+}
+
+// BoolParamE return named param of bool, 400 HTTPError if the param is missing or not bool
+func (ctx *Context) BoolParamE(name string) (bool, error) {
+	boolVal, err := strconv.ParseBool(ctx.Param(name))
+	if err != nil {
+		return false, ctx.paramError(name, err)
+	}
+	return boolVal, nil
+}
+
+func (ctx *Context) paramError(name string, err error) error {
+	if ctx.Param(name) == "" {
+		return NewHTTPError(http.StatusBadRequest, "Missing param "+name).Wrap(err)
+	}
+	return NewHTTPError(http.StatusBadRequest, "Invalid param "+name).Wrap(err)
+}
+
 // transformParamMap transform param map
 func (ctx *Context) transformParamMap(multiFunc func(name string, params []string) string) map[string]string {
 	sm := make(map[string]string, 0)
@@ -123,11 +167,20 @@ func (ctx *Context) Key() string {
 	return ctx.Param("RESTFUL_KEY")
 }
 
-// IntKey return int REST-ful key
+// IntKey return int REST-ful key, panics the error of IntKeyE
 func (ctx *Context) IntKey() int64 {
-	intID, err := strconv.ParseInt(ctx.Key(), 10, 64)
+	intID, err := ctx.IntKeyE()
 	if err != nil {
 		panic(err)
 	}
 	return intID
 }
+
+// IntKeyE return int REST-ful key, 400 HTTPError if the key is not int
+func (ctx *Context) IntKeyE() (int64, error) {
+	intID, err := strconv.ParseInt(ctx.Key(), 10, 64)
+	if err != nil {
+		return 0, NewHTTPError(http.StatusBadRequest, "Invalid key").Wrap(err)
+	}
+	return intID, nil
+}
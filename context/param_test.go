@@ -122,3 +122,52 @@ func TestIntKeyPanic(t *testing.T) {
 	ctx.SetParamMap(map[string][]string{"RESTFUL_KEY": {"hello"}}, false)
 	ctx.IntKey()
 }
+
+func TestIntKeyE(t *testing.T) {
+	ctx := New()
+	ctx.Allocate(buildParamReq(), &config.Template{})
+	ctx.SetParamMap(map[string][]string{"RESTFUL_KEY": {"100"}}, false)
+	id, err := ctx.IntKeyE()
+	require.Nil(t, err)
+	require.Equal(t, int64(100), id)
+	ctx.SetParamMap(map[string][]string{"RESTFUL_KEY": {"hello"}}, false)
+	_, err = ctx.IntKeyE()
+	require.Equal(t, http.StatusBadRequest, err.(*HTTPError).Status)
+}
+
+func TestParamE(t *testing.T) {
+	ctx := New()
+	ctx.Allocate(buildParamReqWithPS("&banana=hello&neg=-1&pi=3.14&ok=true"), &config.Template{})
+	i, err := ctx.IntParamE("apple")
+	require.Nil(t, err)
+	require.Equal(t, int64(100), i)
+	i, err = ctx.IntParamE("neg")
+	require.Nil(t, err)
+	require.Equal(t, int64(-1), i)
+	u, err := ctx.UintParamE("apple")
+	require.Nil(t, err)
+	require.Equal(t, uint64(100), u)
+	f, err := ctx.FloatParamE("pi")
+	require.Nil(t, err)
+	require.Equal(t, 3.14, f)
+	b, err := ctx.BoolParamE("ok")
+	require.Nil(t, err)
+	require.True(t, b)
+
+	for _, fn := range []func(name string) error{
+		func(name string) error { _, err := ctx.IntParamE(name); return err },
+		func(name string) error { _, err := ctx.UintParamE(name); return err },
+		func(name string) error { _, err := ctx.FloatParamE(name); return err },
+		func(name string) error { _, err := ctx.BoolParamE(name); return err },
+	} {
+		err := fn("banana").(*HTTPError)
+		require.Equal(t, http.StatusBadRequest, err.Status)
+		require.Equal(t, "Invalid param banana", err.Message)
+		require.NotNil(t, err.Unwrap())
+		err = fn("none").(*HTTPError)
+		require.Equal(t, http.StatusBadRequest, err.Status)
+		require.Equal(t, "Missing param none", err.Message)
+	}
+	_, err = ctx.UintParamE("neg")
+	require.Equal(t, "Invalid param neg", err.(*HTTPError).Message)
+}
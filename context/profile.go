@@ -0,0 +1,33 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+// SetProfiles set the active configuration profiles
+func (ctx *Context) SetProfiles(profiles []string) *Context {
+	ctx.profiles = profiles
+	return ctx
+}
+
+// Profiles return the active configuration profiles
+func (ctx *Context) Profiles() []string {
+	return ctx.profiles
+}
+
+// HasProfile return true if the named profile is active
+func (ctx *Context) HasProfile(name string) bool {
+	for _, p := range ctx.profiles {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"testing"
+
+	"github.com/go-the-way/anoweb/config"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextProfiles(t *testing.T) {
+	ctx := New()
+	ctx.Allocate(buildReq(""), &config.Template{})
+	require.Nil(t, ctx.Profiles())
+	require.False(t, ctx.HasProfile("prod"))
+	ctx.SetProfiles([]string{"prod", "eu"})
+	require.Equal(t, []string{"prod", "eu"}, ctx.Profiles())
+	require.True(t, ctx.HasProfile("eu"))
+	require.False(t, ctx.HasProfile("dev"))
+}
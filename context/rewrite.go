@@ -23,3 +23,13 @@ func (ctx *Context) Redirect(url string) {
 		headers.Location: []string{url},
 	}).Status(http.StatusTemporaryRedirect).Build()
 }
+
+// RedirectToRoute redirects to the URL of the named route, return the error building the URL
+func (ctx *Context) RedirectToRoute(name string, params ...interface{}) error {
+	url, err := ctx.URL(name, params...)
+	if err != nil {
+		return err
+	}
+	ctx.Redirect(url)
+	return nil
+}
@@ -0,0 +1,165 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	stdctx "context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-the-way/anoweb/headers"
+	"github.com/go-the-way/anoweb/mime"
+)
+
+// Event a Server-Sent Event
+type Event struct {
+	// ID event id, sent back by the client as Last-Event-ID on reconnect
+	ID string
+	// Event event type
+	Event string
+	// Data event data, multi-line data is sent as multiple data lines
+	Data string
+	// Retry reconnection time of the client
+	Retry time.Duration
+}
+
+// ErrSSEClosed the event writer is closed, the handler creating it has returned
+var ErrSSEClosed = errors.New("context: sse writer closed")
+
+// SSEWriter writes Server-Sent Events, it's safe for concurrent use while the handler runs.
+//
+// The sending must stop when the handler returns, the Context is released then,
+// and the writes fail with ErrSSEClosed.
+type SSEWriter struct {
+	w           *ResponseWriter
+	reqCtx      stdctx.Context
+	lastEventID string
+	mu          sync.Mutex
+	closed      bool
+}
+
+// SSE commits the response as text/event-stream and return the event writer,
+// the write deadline of the http server, e.g. WriteTimeout, is cleared for the long-lived response
+func (ctx *Context) SSE() *SSEWriter {
+	h := ctx.Response.Header
+	h.Set(headers.CacheControl, "no-cache")
+	h.Set("X-Accel-Buffering", "no")
+	ctx.Response.ContentType = mime.SSE
+	w := ctx.Writer()
+	_ = w.SetWriteDeadline(time.Time{})
+	w.WriteHeader(http.StatusOK)
+	w.Flush()
+	s := &SSEWriter{w: w, reqCtx: ctx.Request.Context(), lastEventID: ctx.Request.Header.Get(headers.LastEventID)}
+	if s.lastEventID == "" {
+		s.lastEventID = ctx.Param("lastEventId")
+	}
+	w.closers = append(w.closers, s)
+	return s
+}
+
+// LastEventID return the Last-Event-ID header, or the lastEventId param of reconnecting polyfills
+func (s *SSEWriter) LastEventID() string {
+	return s.lastEventID
+}
+
+// Done return the channel closed when the client disconnects
+func (s *SSEWriter) Done() <-chan struct{} {
+	return s.reqCtx.Done()
+}
+
+// Close stops the writes, called when the request is done
+func (s *SSEWriter) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+// Send writes the event and flushes
+func (s *SSEWriter) Send(e *Event) error {
+	var b strings.Builder
+	if e.ID != "" {
+		b.WriteString("id: " + singleLine(e.ID) + "\n")
+	}
+	if e.Event != "" {
+		b.WriteString("event: " + singleLine(e.Event) + "\n")
+	}
+	if e.Retry > 0 {
+		b.WriteString(fmt.Sprintf("retry: %d\n", e.Retry.Milliseconds()))
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(e.Data, "\r\n", "\n"), "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+	return s.write(b.String())
+}
+
+// Data writes the data only event
+func (s *SSEWriter) Data(data string) error {
+	return s.Send(&Event{Data: data})
+}
+
+// JSON writes the event with the data marshalled from v
+func (s *SSEWriter) JSON(event string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.Send(&Event{Event: event, Data: string(data)})
+}
+
+// Retry sets the reconnection time of the client
+func (s *SSEWriter) Retry(retry time.Duration) error {
+	return s.write(fmt.Sprintf("retry: %d\n\n", retry.Milliseconds()))
+}
+
+// Comment writes the comment, ignored by clients
+func (s *SSEWriter) Comment(text string) error {
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		b.WriteString(": " + line + "\n")
+	}
+	b.WriteString("\n")
+	return s.write(b.String())
+}
+
+// Heartbeat writes an empty comment, keeps the connection from idle timeouts
+func (s *SSEWriter) Heartbeat() error {
+	return s.write(":\n\n")
+}
+
+func (s *SSEWriter) write(str string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrSSEClosed
+	}
+	select {
+	case <-s.Done():
+		return s.reqCtx.Err()
+	default:
+	}
+	if _, err := s.w.Write([]byte(str)); err != nil {
+		return err
+	}
+	s.w.Flush()
+	return nil
+}
+
+func singleLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
@@ -0,0 +1,100 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	stdctx "context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-the-way/anoweb/mime"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSE(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/events?lastEventId=7", nil)
+	ctx := New()
+	ctx.Allocate(req, nil)
+	ctx.SetResponseWriter(rec)
+	w := ctx.SSE()
+	require.True(t, ctx.Committed())
+	require.Equal(t, mime.SSE, rec.Header().Get("Content-Type"))
+	require.Equal(t, "no-cache", rec.Header().Get("Cache-Control"))
+	require.Empty(t, rec.Header().Get("Connection"))
+	require.Equal(t, "7", w.LastEventID())
+	require.Nil(t, w.Send(&Event{ID: "1\n", Event: "update", Data: "a\nb", Retry: 3 * time.Second}))
+	require.Nil(t, w.Data("c"))
+	require.Nil(t, w.JSON("json", map[string]int{"a": 1}))
+	require.Error(t, w.JSON("json", func() {}))
+	require.Nil(t, w.Retry(time.Second))
+	require.Nil(t, w.Comment("x\ny"))
+	require.Nil(t, w.Heartbeat())
+	require.Equal(t, "id: 1\nevent: update\nretry: 3000\ndata: a\ndata: b\n\n"+
+		"data: c\n\n"+
+		"event: json\ndata: {\"a\":1}\n\n"+
+		"retry: 1000\n\n"+
+		": x\n: y\n\n"+
+		":\n\n", rec.Body.String())
+	require.True(t, rec.Flushed)
+}
+
+func TestSSEDisconnect(t *testing.T) {
+	reqCtx, cancel := stdctx.WithCancel(stdctx.Background())
+	req := httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(reqCtx)
+	req.Header.Set("Last-Event-ID", "9")
+	ctx := New()
+	ctx.Allocate(req, nil)
+	ctx.SetResponseWriter(httptest.NewRecorder())
+	w := ctx.SSE()
+	require.Equal(t, "9", w.LastEventID())
+	cancel()
+	<-w.Done()
+	require.Equal(t, stdctx.Canceled, w.Data("a"))
+}
+
+func TestSSEReleased(t *testing.T) {
+	rec := httptest.NewRecorder()
+	ctx := New()
+	ctx.Allocate(httptest.NewRequest(http.MethodGet, "/events", nil), nil)
+	ctx.SetResponseWriter(rec)
+	w := ctx.SSE()
+	require.Nil(t, w.Data("a"))
+	require.Nil(t, ctx.Writer().Close())
+	ctx.Reset()
+	require.Equal(t, ErrSSEClosed, w.Data("b"))
+	require.Equal(t, "data: a\n\n", rec.Body.String())
+}
+
+func TestSSEWriteTimeout(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		ctx := New()
+		ctx.Allocate(r, nil)
+		ctx.SetResponseWriter(rw)
+		w := ctx.SSE()
+		time.Sleep(300 * time.Millisecond)
+		_ = w.Data("late")
+	}))
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	defer server.Close()
+	resp, err := http.Get(server.URL)
+	require.Nil(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	require.Nil(t, err)
+	require.Equal(t, "data: late\n\n", string(body))
+}
@@ -0,0 +1,80 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	stdctx "context"
+	"time"
+)
+
+// Context implements the std context.Context with the request context,
+// so handlers observe client disconnects and deadlines, and pass ctx to the downstream libraries
+var _ stdctx.Context = (*Context)(nil)
+
+type dataContext struct {
+	stdctx.Context
+	ctx *Context
+}
+
+func (c *dataContext) Value(key interface{}) interface{} {
+	if name, ok := key.(string); ok {
+		if value, have := c.ctx.dataMap[name]; have {
+			return value
+		}
+	}
+	return c.Context.Value(key)
+}
+
+// Std return the std context of the request, the values set by SetData are available by their names
+func (ctx *Context) Std() stdctx.Context {
+	return &dataContext{ctx.requestContext(), ctx}
+}
+
+// SetStd replaces the std context of the request, e.g. to add a deadline or values
+func (ctx *Context) SetStd(c stdctx.Context) *Context {
+	if ctx.Request != nil {
+		ctx.Request = ctx.Request.WithContext(c)
+	}
+	return ctx
+}
+
+// Deadline implements the std context.Context
+func (ctx *Context) Deadline() (time.Time, bool) {
+	return ctx.requestContext().Deadline()
+}
+
+// Done implements the std context.Context, closed when the client is gone or the deadline exceeded
+func (ctx *Context) Done() <-chan struct{} {
+	return ctx.requestContext().Done()
+}
+
+// Err implements the std context.Context
+func (ctx *Context) Err() error {
+	return ctx.requestContext().Err()
+}
+
+// Value implements the std context.Context, see Std
+func (ctx *Context) Value(key interface{}) interface{} {
+	if name, ok := key.(string); ok {
+		if value, have := ctx.dataMap[name]; have {
+			return value
+		}
+	}
+	return ctx.requestContext().Value(key)
+}
+
+func (ctx *Context) requestContext() stdctx.Context {
+	if ctx.Request == nil {
+		return stdctx.Background()
+	}
+	return ctx.Request.Context()
+}
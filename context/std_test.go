@@ -0,0 +1,65 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	stdctx "context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type stdKey struct{}
+
+func TestContextStd(t *testing.T) {
+	reqCtx, cancel := stdctx.WithCancel(stdctx.WithValue(stdctx.Background(), stdKey{}, "request"))
+	ctx := New()
+	ctx.Allocate(httptest.NewRequest(http.MethodGet, "/", nil).WithContext(reqCtx), nil)
+	ctx.SetData("name", "apple")
+
+	_, ok := ctx.Deadline()
+	require.False(t, ok)
+	require.Nil(t, ctx.Err())
+	require.Equal(t, "apple", ctx.Value("name"))
+	require.Equal(t, "request", ctx.Value(stdKey{}))
+	require.Nil(t, ctx.Value("none"))
+
+	std := ctx.Std()
+	require.Equal(t, "apple", std.Value("name"))
+	require.Equal(t, "request", std.Value(stdKey{}))
+	child, childCancel := stdctx.WithTimeout(ctx, time.Hour)
+	defer childCancel()
+	require.Equal(t, "apple", child.Value("name"))
+
+	cancel()
+	<-ctx.Done()
+	<-child.Done()
+	require.Equal(t, stdctx.Canceled, ctx.Err())
+	require.Equal(t, stdctx.Canceled, std.Err())
+}
+
+func TestContextSetStd(t *testing.T) {
+	ctx := New()
+	require.Equal(t, stdctx.Background(), ctx.requestContext())
+	require.Nil(t, ctx.SetStd(stdctx.TODO()).Request)
+	ctx.Allocate(httptest.NewRequest(http.MethodGet, "/", nil), nil)
+	deadline := time.Now().Add(time.Minute)
+	c, cancel := stdctx.WithDeadline(ctx.Request.Context(), deadline)
+	defer cancel()
+	ctx.SetStd(c)
+	d, ok := ctx.Deadline()
+	require.True(t, ok)
+	require.Equal(t, deadline, d)
+}
@@ -0,0 +1,248 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/go-the-way/anoweb/headers"
+)
+
+var (
+	// ErrNoResponseWriter the Context has no underlying http.ResponseWriter
+	ErrNoResponseWriter = errors.New("context: no response writer")
+	// ErrDeadlineNotSupported the underlying http.ResponseWriter does not support the deadlines
+	ErrDeadlineNotSupported = errors.New("context: response writer does not support deadlines")
+)
+
+// ResponseWriter the streaming writer of Context.
+//
+// The first Write, WriteHeader or Flush commits the status, headers and cookies of the Response once,
+// later writes bypass Response.Data and go straight to the client.
+type ResponseWriter struct {
+	ctx       *Context
+	w         http.ResponseWriter
+	out       io.Writer
+	closers   []io.Closer
+	hooks     []func(w *ResponseWriter)
+	committed bool
+	hijacked  bool
+	written   int64
+}
+
+func newResponseWriter(ctx *Context, w http.ResponseWriter) *ResponseWriter {
+	return &ResponseWriter{ctx: ctx, w: w, out: w}
+}
+
+// SetResponseWriter sets the underlying http.ResponseWriter for streaming
+func (ctx *Context) SetResponseWriter(w http.ResponseWriter) *Context {
+	ctx.writer = newResponseWriter(ctx, w)
+	return ctx
+}
+
+// Writer return the streaming writer
+func (ctx *Context) Writer() *ResponseWriter {
+	if ctx.writer == nil {
+		ctx.writer = newResponseWriter(ctx, nil)
+	}
+	return ctx.writer
+}
+
+// Committed reports whether the response is streamed and already committed
+func (ctx *Context) Committed() bool {
+	return ctx.writer != nil && ctx.writer.committed
+}
+
+// Stream copies r to the client, flushing after each chunk,
+// the write deadline of the http server, e.g. WriteTimeout, applies,
+// extend or clear it by ctx.Writer().SetWriteDeadline for the long-lived response
+func (ctx *Context) Stream(r io.Reader) error {
+	w := ctx.Writer()
+	buf := make([]byte, 32<<10)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if _, wErr := w.Write(buf[:n]); wErr != nil {
+				return wErr
+			}
+			w.Flush()
+		}
+		if err == io.EOF {
+			return w.commit()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Header return the Response header before commit, the sent header after
+func (w *ResponseWriter) Header() http.Header {
+	if w.committed && w.w != nil {
+		return w.w.Header()
+	}
+	return w.ctx.Response.Header
+}
+
+// WriteHeader sets the status and commits
+func (w *ResponseWriter) WriteHeader(status int) {
+	if !w.committed {
+		w.ctx.Response.Status = status
+		_ = w.commit()
+	}
+}
+
+// Write commits and writes b to the client
+func (w *ResponseWriter) Write(b []byte) (int, error) {
+	if err := w.commit(); err != nil {
+		return 0, err
+	}
+	n, err := w.out.Write(b)
+	w.written += int64(n)
+	return n, err
+}
+
+// Flush commits and flushes the buffered data to the client
+func (w *ResponseWriter) Flush() {
+	if err := w.commit(); err != nil {
+		return
+	}
+	for i := len(w.closers) - 1; i >= 0; i-- {
+		if f, ok := w.closers[i].(interface{ Flush() error }); ok {
+			_ = f.Flush()
+		}
+	}
+	if f, ok := w.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Committed reports whether the status and headers are sent
+func (w *ResponseWriter) Committed() bool {
+	return w.committed
+}
+
+// Written return the number of body bytes written, before any filter
+func (w *ResponseWriter) Written() int64 {
+	return w.written
+}
+
+// Hijack takes over the connection, e.g. for WebSocket, the response is committed without writing
+func (w *ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if w.committed {
+		return nil, nil, errors.New("context: hijack after commit")
+	}
+	hijacker, ok := w.w.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("context: response writer does not support hijacking")
+	}
+	conn, brw, err := hijacker.Hijack()
+	if err == nil {
+		w.committed = true
+		w.hijacked = true
+	}
+	return conn, brw, err
+}
+
+// Hijacked reports whether the connection is hijacked
+func (w *ResponseWriter) Hijacked() bool {
+	return w.hijacked
+}
+
+// SetWriteDeadline sets the write deadline of the connection, the zero time clears it,
+// like http.ResponseController for the writers wrapped with Unwrap
+func (w *ResponseWriter) SetWriteDeadline(deadline time.Time) error {
+	rw := w.w
+	for rw != nil {
+		if d, ok := rw.(interface{ SetWriteDeadline(time.Time) error }); ok {
+			return d.SetWriteDeadline(deadline)
+		}
+		u, ok := rw.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			break
+		}
+		rw = u.Unwrap()
+	}
+	return ErrDeadlineNotSupported
+}
+
+// Unwrap return the underlying http.ResponseWriter
+func (w *ResponseWriter) Unwrap() http.ResponseWriter {
+	return w.w
+}
+
+// OnCommit adds the hook called right before commit, e.g. to set headers or add a Filter
+func (w *ResponseWriter) OnCommit(hook func(w *ResponseWriter)) {
+	w.hooks = append(w.hooks, hook)
+}
+
+// Filter wraps the output, e.g. with a compressor, the filter is closed by Close.
+// It must be added before commit, usually by an OnCommit hook.
+func (w *ResponseWriter) Filter(wrap func(out io.Writer) io.WriteCloser) {
+	wc := wrap(w.out)
+	w.out = wc
+	w.closers = append(w.closers, wc)
+}
+
+// Close closes the filters in reverse order, called when the request is done
+func (w *ResponseWriter) Close() error {
+	var err error
+	for i := len(w.closers) - 1; i >= 0; i-- {
+		if cErr := w.closers[i].Close(); cErr != nil && err == nil {
+			err = cErr
+		}
+	}
+	w.closers = nil
+	return err
+}
+
+func (w *ResponseWriter) commit() error {
+	if w.committed {
+		return nil
+	}
+	if w.w == nil {
+		return ErrNoResponseWriter
+	}
+	r := w.ctx.Response
+	if contentType := r.Header.Get(headers.MIME); contentType != "" {
+		r.ContentType = contentType
+	}
+	for _, hook := range w.hooks {
+		hook(w)
+	}
+	w.committed = true
+	WriteHeader(r, w.w)
+	return nil
+}
+
+// WriteHeader writes the status, headers and cookies of r to w
+func WriteHeader(r *Response, w http.ResponseWriter) {
+	for k, v := range r.Header {
+		for _, vv := range v {
+			w.Header().Add(k, vv)
+		}
+	}
+	if r.ContentType != "" {
+		w.Header().Set(headers.MIME, r.ContentType)
+	}
+	for _, cookie := range r.Cookies {
+		w.Header().Add(headers.SetCookie, cookie.String())
+	}
+	if r.Status != 0 {
+		w.WriteHeader(r.Status)
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-the-way/anoweb/mime"
+
+	"github.com/stretchr/testify/require"
+)
+
+type upperFilter struct {
+	w       io.Writer
+	flushed int
+	closed  bool
+}
+
+func (f *upperFilter) Write(b []byte) (int, error) {
+	return f.w.Write([]byte(strings.ToUpper(string(b))))
+}
+
+func (f *upperFilter) Flush() error {
+	f.flushed++
+	return nil
+}
+
+func (f *upperFilter) Close() error {
+	f.closed = true
+	return nil
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, errors.New("read error")
+}
+
+func TestResponseWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	ctx := New()
+	ctx.Allocate(httptest.NewRequest(http.MethodGet, "/", nil), nil)
+	ctx.SetResponseWriter(rec)
+	ctx.AddCookie(&http.Cookie{Name: "a", Value: "b"})
+	w := ctx.Writer()
+	require.False(t, ctx.Committed())
+	w.Header().Set("X-A", "a")
+	require.Equal(t, "a", ctx.Response.Header.Get("X-A"))
+	filter := &upperFilter{}
+	hooked := 0
+	w.OnCommit(func(w *ResponseWriter) {
+		hooked++
+		w.Filter(func(out io.Writer) io.WriteCloser {
+			filter.w = out
+			return filter
+		})
+	})
+	w.WriteHeader(http.StatusAccepted)
+	w.WriteHeader(http.StatusOK)
+	require.True(t, ctx.Committed())
+	require.False(t, rec.Flushed)
+	_, err := w.Write([]byte("hello "))
+	require.Nil(t, err)
+	w.Flush()
+	_, _ = io.WriteString(w, "world")
+	require.Nil(t, w.Close())
+	require.Equal(t, 1, hooked)
+	require.Equal(t, 1, filter.flushed)
+	require.True(t, filter.closed)
+	require.True(t, rec.Flushed)
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	require.Equal(t, "HELLO WORLD", rec.Body.String())
+	require.Equal(t, int64(11), w.Written())
+	require.Equal(t, "a", rec.Header().Get("X-A"))
+	require.Equal(t, mime.TEXT, rec.Header().Get("Content-Type"))
+	require.Equal(t, "a=b", rec.Header().Get("Set-Cookie"))
+	require.Equal(t, rec.Header(), w.Header())
+	require.Equal(t, rec, w.Unwrap())
+}
+
+func TestResponseWriterContentType(t *testing.T) {
+	rec := httptest.NewRecorder()
+	ctx := New()
+	ctx.SetResponseWriter(rec)
+	ctx.Writer().Header().Set("Content-Type", mime.JSON)
+	_, _ = ctx.Writer().Write([]byte("{}"))
+	require.Equal(t, mime.JSON, ctx.Response.ContentType)
+	require.Equal(t, mime.JSON, rec.Header().Get("Content-Type"))
+}
+
+func TestResponseWriterNoWriter(t *testing.T) {
+	ctx := New()
+	_, err := ctx.Writer().Write([]byte("a"))
+	require.Equal(t, ErrNoResponseWriter, err)
+	require.False(t, ctx.Committed())
+	ctx.Writer().Flush()
+	require.Equal(t, ErrNoResponseWriter, ctx.Stream(strings.NewReader("a")))
+}
+
+func TestStream(t *testing.T) {
+	{
+		rec := httptest.NewRecorder()
+		ctx := New()
+		ctx.SetResponseWriter(rec)
+		data := strings.Repeat("a", 100<<10)
+		require.Nil(t, ctx.Stream(strings.NewReader(data)))
+		require.Equal(t, data, rec.Body.String())
+		require.True(t, rec.Flushed)
+	}
+	{
+		rec := httptest.NewRecorder()
+		ctx := New()
+		ctx.SetResponseWriter(rec)
+		require.Nil(t, ctx.Stream(strings.NewReader("")))
+		require.True(t, ctx.Committed())
+		require.Equal(t, http.StatusOK, rec.Code)
+	}
+	{
+		ctx := New()
+		ctx.SetResponseWriter(httptest.NewRecorder())
+		require.Error(t, ctx.Stream(errReader{}))
+	}
+}
+
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	conn net.Conn
+}
+
+func (h *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return h.conn, bufio.NewReadWriter(bufio.NewReader(h.conn), bufio.NewWriter(h.conn)), nil
+}
+
+func TestResponseWriterHijack(t *testing.T) {
+	{
+		ctx := New()
+		ctx.Allocate(httptest.NewRequest(http.MethodGet, "/", nil), nil)
+		ctx.SetResponseWriter(httptest.NewRecorder())
+		_, _, err := ctx.Writer().Hijack()
+		require.Error(t, err)
+		require.False(t, ctx.Writer().Hijacked())
+	}
+	{
+		ctx := New()
+		ctx.Allocate(httptest.NewRequest(http.MethodGet, "/", nil), nil)
+		ctx.SetResponseWriter(&hijackRecorder{ResponseRecorder: httptest.NewRecorder()})
+		ctx.Writer().WriteHeader(http.StatusOK)
+		_, _, err := ctx.Writer().Hijack()
+		require.Error(t, err)
+	}
+	{
+		c1, c2 := net.Pipe()
+		defer func() { _ = c1.Close(); _ = c2.Close() }()
+		ctx := New()
+		ctx.Allocate(httptest.NewRequest(http.MethodGet, "/", nil), nil)
+		ctx.SetResponseWriter(&hijackRecorder{ResponseRecorder: httptest.NewRecorder(), conn: c1})
+		conn, brw, err := ctx.Writer().Hijack()
+		require.Nil(t, err)
+		require.Equal(t, c1, conn)
+		require.NotNil(t, brw)
+		require.True(t, ctx.Committed())
+		require.True(t, ctx.Writer().Hijacked())
+	}
+}
+
+type deadlineWriter struct {
+	http.ResponseWriter
+	deadline *time.Time
+}
+
+func (w deadlineWriter) SetWriteDeadline(deadline time.Time) error {
+	*w.deadline = deadline
+	return nil
+}
+
+type unwrapWriter struct {
+	http.ResponseWriter
+}
+
+func (w unwrapWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func TestResponseWriterSetWriteDeadline(t *testing.T) {
+	now := time.Now()
+	deadline := now
+	ctx := New()
+	ctx.Allocate(httptest.NewRequest(http.MethodGet, "/", nil), nil)
+	ctx.SetResponseWriter(unwrapWriter{deadlineWriter{httptest.NewRecorder(), &deadline}})
+	require.Nil(t, ctx.Stream(strings.NewReader("hello")))
+	require.Equal(t, now, deadline)
+	require.Nil(t, ctx.Writer().SetWriteDeadline(time.Time{}))
+	require.True(t, deadline.IsZero())
+
+	ctx.SetResponseWriter(unwrapWriter{httptest.NewRecorder()})
+	require.Equal(t, ErrDeadlineNotSupported, ctx.Writer().SetWriteDeadline(time.Time{}))
+	require.Equal(t, ErrDeadlineNotSupported, New().Writer().SetWriteDeadline(time.Time{}))
+}
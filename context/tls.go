@@ -0,0 +1,35 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+)
+
+// ClientCertificate return the verified client certificate, nil if the client was not verified
+func (ctx *Context) ClientCertificate() *x509.Certificate {
+	if state := ctx.Request.TLS; state != nil {
+		if len(state.VerifiedChains) > 0 && len(state.VerifiedChains[0]) > 0 {
+			return state.VerifiedChains[0][0]
+		}
+	}
+	return nil
+}
+
+// ClientSubject return the verified client certificate subject, nil if the client was not verified
+func (ctx *Context) ClientSubject() *pkix.Name {
+	if cert := ctx.ClientCertificate(); cert != nil {
+		return &cert.Subject
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/go-the-way/anoweb/config"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextClientCertificate(t *testing.T) {
+	{
+		ctx := New()
+		ctx.Allocate(buildReq(""), &config.Template{})
+		require.Nil(t, ctx.ClientCertificate())
+		require.Nil(t, ctx.ClientSubject())
+	}
+	{
+		ctx := New()
+		req := buildReq("")
+		req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "unverified"}}}}
+		ctx.Allocate(req, &config.Template{})
+		require.Nil(t, ctx.ClientCertificate())
+	}
+	{
+		ctx := New()
+		req := buildReq("")
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: "client"}}
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		ctx.Allocate(req, &config.Template{})
+		require.Equal(t, cert, ctx.ClientCertificate())
+		require.Equal(t, "client", ctx.ClientSubject().CommonName)
+	}
+}
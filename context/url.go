@@ -0,0 +1,35 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import "errors"
+
+// ErrNoURLFunc no func building the URLs of the named routes
+var ErrNoURLFunc = errors.New("context: no url func")
+
+// SetURLFunc sets the func building the URLs of the named routes,
+// and the template func "url" unless the template config has one
+func (ctx *Context) SetURLFunc(urlFunc func(name string, params ...interface{}) (string, error)) *Context {
+	ctx.urlFunc = urlFunc
+	if _, have := ctx.funcMap["url"]; !have && urlFunc != nil {
+		ctx.funcMap["url"] = urlFunc
+	}
+	return ctx
+}
+
+// URL return the URL of the named route with the params, pairs of name and value
+func (ctx *Context) URL(name string, params ...interface{}) (string, error) {
+	if ctx.urlFunc == nil {
+		return "", ErrNoURLFunc
+	}
+	return ctx.urlFunc(name, params...)
+}
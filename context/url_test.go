@@ -0,0 +1,67 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package context
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/go-the-way/anoweb/config"
+	"github.com/go-the-way/anoweb/headers"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testURLFunc(name string, params ...interface{}) (string, error) {
+	if name != "user" {
+		return "", errors.New("not found")
+	}
+	return fmt.Sprintf("/users/%v", params[1]), nil
+}
+
+func TestURL(t *testing.T) {
+	ctx := New()
+	ctx.Allocate(buildReq(""), nil)
+	_, err := ctx.URL("user", "id", 1)
+	require.Equal(t, ErrNoURLFunc, err)
+	require.Nil(t, ctx.funcMap["url"])
+
+	ctx.SetURLFunc(testURLFunc)
+	url, err := ctx.URL("user", "id", 1)
+	require.Nil(t, err)
+	require.Equal(t, "/users/1", url)
+	ctx.Template(`<a href="{{url "user" "id" 2}}">`, nil)
+	require.Equal(t, `<a href="/users/2">`, string(ctx.Response.Data))
+
+	ctx.Reset()
+	ctx.Allocate(buildReq(""), &config.Template{FuncMap: map[string]interface{}{"url": func(name string) string { return "custom" }}})
+	ctx.SetURLFunc(testURLFunc)
+	ctx.Template(`{{url "user"}}`, nil)
+	require.Equal(t, "custom", string(ctx.Response.Data))
+	_, err = ctx.URL("none")
+	require.EqualError(t, err, "not found")
+}
+
+func TestRedirectToRoute(t *testing.T) {
+	ctx := New()
+	ctx.Allocate(buildReq(""), nil)
+	ctx.SetURLFunc(testURLFunc)
+	require.Nil(t, ctx.RedirectToRoute("user", "id", 3))
+	require.Equal(t, http.StatusTemporaryRedirect, ctx.Response.Status)
+	require.Equal(t, "/users/3", ctx.Response.Header.Get(headers.Location))
+	ctx.Reset()
+	ctx.Allocate(buildReq(""), nil)
+	require.Equal(t, ErrNoURLFunc, ctx.RedirectToRoute("user", "id", 3))
+	require.Equal(t, http.StatusOK, ctx.Response.Status)
+}
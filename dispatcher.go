@@ -13,7 +13,6 @@ package anoweb
 
 import (
 	"github.com/go-the-way/anoweb/context"
-	"github.com/go-the-way/anoweb/headers"
 	"github.com/go-the-way/anoweb/middleware"
 	"net/http"
 )
@@ -31,11 +30,32 @@ func (d *dispatcher) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 func (d *dispatcher) dispatch(r *http.Request, w http.ResponseWriter) {
-	ctx := d.ctxPool.Get().(*context.Context)
-	ctx.Allocate(r, d.App.Config.Template)
+	ctx := d.acquire(r, w)
+	defer d.release(ctx)
 	d.addChains(ctx, d.App.parsedRouters.Handler(ctx), d.App.Middlewares())
 	ctx.Chain()
-	d.writeDone(ctx.Response, w)
+	if !ctx.Committed() {
+		d.writeDone(ctx.Response, w)
+	}
+	_ = ctx.Writer().Close()
+}
+
+// acquire return the reset context from the pool
+func (d *dispatcher) acquire(r *http.Request, w http.ResponseWriter) *context.Context {
+	ctx := d.ctxPool.Get().(*context.Context)
+	ctx.Reset()
+	ctx.Allocate(r, d.App.Config.Template)
+	ctx.SetProfiles(d.App.profiles)
+	ctx.SetURLFunc(d.App.urlFunc)
+	ctx.SetErrorHandler(d.App.errorHandler)
+	ctx.SetResponseWriter(w)
+	return ctx
+}
+
+// release destroys the context and puts it back to the pool, it runs also when a handler panics
+func (d *dispatcher) release(ctx *context.Context) {
+	ctx.Destroy()
+	d.ctxPool.Put(ctx)
 }
 
 func (d *dispatcher) addChains(ctx *context.Context, handler func(ctx *context.Context), mws []middleware.Middleware) {
@@ -46,23 +66,7 @@ func (d *dispatcher) addChains(ctx *context.Context, handler func(ctx *context.C
 }
 
 func (d *dispatcher) writeDone(r *context.Response, w http.ResponseWriter) {
-	for k, v := range r.Header {
-		for _, vv := range v {
-			w.Header().Add(k, vv)
-		}
-	}
-
-	if r.ContentType != "" {
-		w.Header().Set(headers.MIME, r.ContentType)
-	}
-
-	for _, cookie := range r.Cookies {
-		w.Header().Add(headers.SetCookie, cookie.String())
-	}
-
-	if r.Status != 0 {
-		w.WriteHeader(r.Status)
-	}
+	context.WriteHeader(r, w)
 
 	if r.Data != nil {
 		_, _ = w.Write(r.Data)
@@ -12,7 +12,9 @@
 package anoweb
 
 import (
+	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
@@ -82,3 +84,63 @@ func TestDispatcherWriteDone(t *testing.T) {
 	require.Equal(t, responseWriter.buf.String(), message)
 	require.Equal(t, responseWriter.statusCode, http.StatusOK)
 }
+
+func TestDispatcherStream(t *testing.T) {
+	a := New().Get("/", func(ctx *context.Context) {
+		ctx.Response.Header.Set("X-Stream", "1")
+		_ = ctx.Stream(strings.NewReader("streamed"))
+		ctx.Text("ignored")
+	}).parseRouters()
+	rec := httptest.NewRecorder()
+	a.newDispatcher().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, "streamed", rec.Body.String())
+	require.Equal(t, "1", rec.Header().Get("X-Stream"))
+	require.True(t, rec.Flushed)
+}
+
+func TestDispatcherPool(t *testing.T) {
+	var created, destroyed int
+	context.AddListeners(&context.Listener{
+		Created:   func(ctx *context.Context) { created++ },
+		Destroyed: func(ctx *context.Context) { destroyed++ },
+	})
+	defer context.ClearListeners()
+	a := New().Get("/", func(ctx *context.Context) {
+		if ctx.Param("panic") != "" {
+			panic("dispatcher")
+		}
+		ctx.Text(fmt.Sprintf("%v-%s", ctx.GetData("name"), ctx.Param("name")))
+		ctx.SetData("name", ctx.Param("name"))
+		ctx.AddCookie(&http.Cookie{Name: ctx.Param("name")})
+	}).parseRouters()
+	d := a.newDispatcher()
+	for _, name := range []string{"apple", "banana"} {
+		rec := httptest.NewRecorder()
+		d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?name="+name, nil))
+		require.Equal(t, "<nil>-"+name, rec.Body.String())
+		require.Len(t, rec.Result().Cookies(), 1)
+	}
+	require.Panics(t, func() {
+		d.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/?panic=1", nil))
+	})
+	require.Equal(t, 3, created)
+	require.Equal(t, 3, destroyed)
+	rec := httptest.NewRecorder()
+	d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?name=cherry", nil))
+	require.Equal(t, "<nil>-cherry", rec.Body.String())
+}
+
+func BenchmarkDispatcher(b *testing.B) {
+	a := New().Get("/", func(ctx *context.Context) {
+		ctx.Text("hello")
+	}).parseRouters()
+	d := a.newDispatcher()
+	req := httptest.NewRequest(http.MethodGet, "/?name=apple", nil)
+	w := &_responseWriter{}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w.buf.Reset()
+		d.dispatch(req, w)
+	}
+}
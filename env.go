@@ -13,65 +13,24 @@ package anoweb
 
 import (
 	"os"
-	"strconv"
-	"time"
-)
 
-const (
-	envServerMaxHeaderSize     = "SERVER_MAX_HEADER_SIZE"
-	envServerReadTimeout       = "SERVER_READ_TIMEOUT"
-	envServerReadHeaderTimeout = "SERVER_READ_HEADER_TIMEOUT"
-	envServerWriteTimeout      = "SERVER_WRITE_TIMEOUT"
-	envServerIdleTimeout       = "SERVER_IDLE_TIMEOUT"
-	envConfigFile              = "CONFIG_FILE"
-	envServerHost              = "SERVER_HOST"
-	envServerPort              = "SERVER_PORT"
-	envServerTLSEnable         = "SERVER_TLS_ENABLE"
-	envServerTLSCertFile       = "SERVER_TLS_CERT_FILE"
-	envServerTLSKeyFile        = "SERVER_TLS_KEY_FILE"
-	envBannerEnable            = "BANNER_ENABLE"
-	envBannerType              = "BANNER_TYPE"
-	envBannerText              = "BANNER_TEXT"
-	envBannerFile              = "BANNER_FILE"
-	envTemplateCache           = "TEMPLATE_CACHE"
-	envTemplateRoot            = "TEMPLATE_ROOT"
-	envTemplateSuffix          = "TEMPLATE_SUFFIX"
+	"github.com/go-the-way/anoweb/config"
 )
 
-func (a *App) setServerMaxHeaderSize() {
-	maxHeaderSize, err := intEnv(envServerMaxHeaderSize)
-	if err == nil {
-		a.Config.Server.MaxHeaderSize = maxHeaderSize
-	}
-}
-
-func (a *App) setServerReadTimeout() {
-	readTimeout, err := durationEnv(envServerReadTimeout)
-	if err == nil {
-		a.Config.Server.ReadTimeout = readTimeout
-	}
-}
+// envPrefix the optional prefix of env names, e.g. ANOWEB_SERVER_PORT takes precedence over SERVER_PORT
+const envPrefix = "ANOWEB_"
 
-func (a *App) setServerReadHeaderTimeout() {
-	readHeaderTimeout, err := durationEnv(envServerReadHeaderTimeout)
-	if err == nil {
-		a.Config.Server.ReadHeaderTimeout = readHeaderTimeout
-	}
-}
-
-func (a *App) setServerWriteTimeout() {
-	writeTimeout, err := durationEnv(envServerWriteTimeout)
-	if err == nil {
-		a.Config.Server.WriteTimeout = writeTimeout
-	}
-}
+const (
+	envConfigFile = "CONFIG_FILE"
+	envProfile    = "ANOWEB_PROFILE"
+)
 
-func (a *App) setServerIdleTimeout() {
-	idleTimeout, err := durationEnv(envServerIdleTimeout)
-	if err == nil {
-		a.Config.Server.IdleTimeout = idleTimeout
-	}
-}
+// config value sources
+const (
+	sourceDefault = "default"
+	sourceYaml    = "yaml"
+	sourceEnv     = "env"
+)
 
 func (a *App) setConfigFile() {
 	configFile := stringEnv(envConfigFile)
@@ -80,127 +39,70 @@ func (a *App) setConfigFile() {
 	}
 }
 
-func (a *App) setServerHost() {
-	host := stringEnv(envServerHost)
-	if host != "" {
-		a.Config.Server.Host = host
-	}
-}
-
-func (a *App) setServerPort() {
-	port, err := intEnv(envServerPort)
-	if err == nil {
-		a.Config.Server.Port = port
-	}
-}
-
-func (a *App) setBannerEnable() {
-	if stringEnv(envBannerEnable) != "" {
-		a.Config.Banner.Enable = boolEnv(envBannerEnable)
-	}
-}
-
-func (a *App) setBannerType() {
-	bannerType := stringEnv(envBannerType)
-	if bannerType != "" {
-		a.Config.Banner.Type = bannerType
+func (a *App) setProfiles() {
+	profiles := os.Getenv(envProfile)
+	if profiles != "" {
+		a.profiles = splitProfiles(profiles)
 	}
 }
 
-func (a *App) setBannerText() {
-	bannerText := stringEnv(envBannerText)
-	if bannerText != "" {
-		a.Config.Banner.Text = bannerText
+func (a *App) parseEnv() error {
+	a.setConfigFile()
+	if a.ConfigFile != "" {
+		if err := a.parseYml(); err != nil {
+			return err
+		}
 	}
-}
-
-func (a *App) setBannerFile() {
-	bannerFile := stringEnv(envBannerFile)
-	if bannerFile != "" {
-		a.Config.Banner.File = bannerFile
+	applied, err := config.Env("", a.Config, lookupEnv)
+	if err != nil {
+		return err
 	}
+	a.configSources = a.sources(applied)
+	return nil
 }
 
-func (a *App) setServerTLSEnable() {
-	if stringEnv(envServerTLSEnable) != "" {
-		a.Config.Server.TLS.Enable = boolEnv(envServerTLSEnable)
+// sources return the source of each config.Config key
+func (a *App) sources(applied map[string]string) map[string]string {
+	fs, _ := config.Fields(a.Config)
+	sources := make(map[string]string, len(fs))
+	for _, f := range fs {
+		key := f.Key()
+		if _, have := applied[key]; have {
+			sources[key] = sourceEnv
+		} else if _, have = config.Lookup(a.configDoc, f.Path...); have {
+			sources[key] = sourceYaml
+		} else {
+			sources[key] = sourceDefault
+		}
 	}
+	return sources
 }
 
-func (a *App) setServerTLSCertFile() {
-	serverTLSCertFile := stringEnv(envServerTLSCertFile)
-	if serverTLSCertFile != "" {
-		a.Config.Server.TLS.CertFile = serverTLSCertFile
+// printConfig logs the effective value of each config.Config key and its source
+func (a *App) printConfig() {
+	fs, _ := config.Fields(a.Config)
+	for _, f := range fs {
+		key := f.Key()
+		source := a.configSources[key]
+		if source == "" {
+			source = sourceDefault
+		}
+		a.logger.Printf("Config %s = %v (%s)", key, f.Value.Interface(), source)
 	}
 }
 
-func (a *App) setServerTLSKeyFile() {
-	serverTLSKeyFile := stringEnv(envServerTLSKeyFile)
-	if serverTLSKeyFile != "" {
-		a.Config.Server.TLS.KeyFile = serverTLSKeyFile
+// lookupEnv lookups the prefixed env first, then the unprefixed one, empty values are ignored
+func lookupEnv(key string) (string, bool) {
+	if val := os.Getenv(envPrefix + key); val != "" {
+		return val, true
 	}
-}
-
-func (a *App) setTemplateCache() {
-	if stringEnv(envTemplateCache) != "" {
-		a.Config.Template.Cache = boolEnv(envTemplateCache)
-	}
-}
-
-func (a *App) setTemplateRoot() {
-	templateRoot := stringEnv(envTemplateRoot)
-	if templateRoot != "" {
-		a.Config.Template.Root = templateRoot
+	if val := os.Getenv(key); val != "" {
+		return val, true
 	}
-}
-
-func (a *App) setTemplateSuffix() {
-	templateSuffix := stringEnv(envTemplateSuffix)
-	if templateSuffix != "" {
-		a.Config.Template.Suffix = templateSuffix
-	}
-}
-
-func (a *App) parseEnv() {
-	a.setConfigFile()
-	if a.ConfigFile != "" {
-		a.parseYml()
-	}
-	a.setServerMaxHeaderSize()
-	a.setServerReadTimeout()
-	a.setServerReadHeaderTimeout()
-	a.setServerWriteTimeout()
-	a.setServerIdleTimeout()
-	a.setServerHost()
-	a.setServerPort()
-	a.setServerTLSEnable()
-	a.setServerTLSCertFile()
-	a.setServerTLSKeyFile()
-	a.setBannerEnable()
-	a.setBannerType()
-	a.setBannerText()
-	a.setBannerFile()
-	a.setTemplateCache()
-	a.setTemplateRoot()
-	a.setTemplateSuffix()
+	return "", false
 }
 
 func stringEnv(key string) string {
-	return os.Getenv(key)
-}
-
-func boolEnv(key string) bool {
-	be := os.Getenv(key)
-	b, err := strconv.ParseBool(be)
-	return err == nil && b
-}
-
-func intEnv(key string) (int, error) {
-	ie := os.Getenv(key)
-	return strconv.Atoi(ie)
-}
-
-func durationEnv(key string) (time.Duration, error) {
-	de := os.Getenv(key)
-	return time.ParseDuration(de)
+	val, _ := lookupEnv(key)
+	return val
 }
@@ -12,14 +12,62 @@
 package anoweb
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
+	"log"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/go-the-way/anoweb/config"
+
 	"github.com/stretchr/testify/require"
 )
 
+// The env names of config.Config for the tests, derived from the yaml key path by config.Field.EnvName
+const (
+	envServerMaxHeaderSize     = "SERVER_MAX_HEADER_SIZE"
+	envServerReadTimeout       = "SERVER_READ_TIMEOUT"
+	envServerReadHeaderTimeout = "SERVER_READ_HEADER_TIMEOUT"
+	envServerWriteTimeout      = "SERVER_WRITE_TIMEOUT"
+	envServerIdleTimeout       = "SERVER_IDLE_TIMEOUT"
+	envServerShutdownTimeout   = "SERVER_SHUTDOWN_TIMEOUT"
+	envServerHTTP2MaxStreams   = "SERVER_HTTP2_MAX_CONCURRENT_STREAMS"
+	envServerHTTP2MaxFrameSize = "SERVER_HTTP2_MAX_READ_FRAME_SIZE"
+	envServerHTTP2IdleTimeout  = "SERVER_HTTP2_IDLE_TIMEOUT"
+	envServerHTTP2ConnBuffer   = "SERVER_HTTP2_MAX_UPLOAD_BUFFER_PER_CONNECTION"
+	envServerHTTP2StreamBuffer = "SERVER_HTTP2_MAX_UPLOAD_BUFFER_PER_STREAM"
+	envServerNetwork           = "SERVER_NETWORK"
+	envServerHost              = "SERVER_HOST"
+	envServerPort              = "SERVER_PORT"
+	envServerSocket            = "SERVER_SOCKET"
+	envServerSocketMode        = "SERVER_SOCKET_MODE"
+	envServerTLSEnable         = "SERVER_TLS_ENABLE"
+	envServerTLSCertFile       = "SERVER_TLS_CERT_FILE"
+	envServerTLSKeyFile        = "SERVER_TLS_KEY_FILE"
+	envServerTLSHTTPPort       = "SERVER_TLS_HTTP_PORT"
+	envServerTLSRedirect       = "SERVER_TLS_REDIRECT"
+	envServerTLSRedirectCode   = "SERVER_TLS_REDIRECT_CODE"
+	envServerTLSHSTSEnable     = "SERVER_TLS_HSTS_ENABLE"
+	envServerTLSHSTSMaxAge     = "SERVER_TLS_HSTS_MAX_AGE"
+	envServerTLSHSTSSubDomains = "SERVER_TLS_HSTS_INCLUDE_SUB_DOMAINS"
+	envServerTLSHSTSPreload    = "SERVER_TLS_HSTS_PRELOAD"
+	envServerTLSWatch          = "SERVER_TLS_WATCH"
+	envServerTLSWatchInterval  = "SERVER_TLS_WATCH_INTERVAL"
+	envServerTLSClientCAFile   = "SERVER_TLS_CLIENT_CA_FILE"
+	envServerTLSClientAuth     = "SERVER_TLS_CLIENT_AUTH"
+	envBannerEnable            = "BANNER_ENABLE"
+	envBannerType              = "BANNER_TYPE"
+	envBannerText              = "BANNER_TEXT"
+	envBannerFile              = "BANNER_FILE"
+	envBannerRoutes            = "BANNER_ROUTES"
+	envTemplateCache           = "TEMPLATE_CACHE"
+	envTemplateRoot            = "TEMPLATE_ROOT"
+	envTemplateSuffix          = "TEMPLATE_SUFFIX"
+)
+
 type testEnvCase struct {
 	env        string
 	val        interface{}
@@ -29,51 +77,94 @@ type testEnvCase struct {
 
 func TestEnv(t *testing.T) {
 	a := New()
+	a.ConfigFile = ""
+	parse := func() { require.Nil(t, a.parseEnv()) }
 	cases := make([]*testEnvCase, 0)
 
 	{
-		// test for setServerMaxHeaderSize
-		cases = append(cases, &testEnvCase{envServerMaxHeaderSize, 100, func() { a.setServerMaxHeaderSize() }, func() interface{} { return a.Config.Server.MaxHeaderSize }})
-		// test for setServerReadTimeout
-		cases = append(cases, &testEnvCase{envServerReadTimeout, "10s", func() { a.setServerReadTimeout() }, func() interface{} { return a.Config.Server.ReadTimeout }})
-		// test for setServerReadHeaderTimeout
-		cases = append(cases, &testEnvCase{envServerReadHeaderTimeout, "10s", func() { a.setServerReadHeaderTimeout() }, func() interface{} { return a.Config.Server.ReadHeaderTimeout }})
-		// test for setServerWriteTimeout
-		cases = append(cases, &testEnvCase{envServerWriteTimeout, "10s", func() { a.setServerWriteTimeout() }, func() interface{} { return a.Config.Server.WriteTimeout }})
-		// test for setServerIdleTimeout
-		cases = append(cases, &testEnvCase{envServerIdleTimeout, "10s", func() { a.setServerIdleTimeout() }, func() interface{} { return a.Config.Server.IdleTimeout }})
-		// test for setConfigFile
-		cases = append(cases, &testEnvCase{envConfigFile, "app.yml", func() { a.setConfigFile() }, func() interface{} { return a.ConfigFile }})
-		// test for setServerHost
-		cases = append(cases, &testEnvCase{envServerHost, "0.0.0.0", func() { a.setServerHost() }, func() interface{} { return a.Config.Server.Host }})
-		// test for setServerPort
-		cases = append(cases, &testEnvCase{envServerPort, 1080, func() { a.setServerPort() }, func() interface{} { return a.Config.Server.Port }})
-		// test for setBannerEnable
-		cases = append(cases, &testEnvCase{envBannerEnable, true, func() { a.setBannerEnable() }, func() interface{} { return a.Config.Banner.Enable }})
-		// test for setBannerType
-		cases = append(cases, &testEnvCase{envBannerType, "default", func() { a.setBannerType() }, func() interface{} { return a.Config.Banner.Type }})
-		// test for setBannerText
-		cases = append(cases, &testEnvCase{envBannerText, "hello world -- GO GO GO", func() { a.setBannerText() }, func() interface{} { return a.Config.Banner.Text }})
-		// test for setBannerFile
-		cases = append(cases, &testEnvCase{envBannerFile, "banner.txt", func() { a.setBannerFile() }, func() interface{} { return a.Config.Banner.File }})
-		// test for setServerTLSEnable
-		cases = append(cases, &testEnvCase{envServerTLSEnable, true, func() { a.setServerTLSEnable() }, func() interface{} { return a.Config.Server.TLS.Enable }})
-		// test for envServerTLSCertFile
-		cases = append(cases, &testEnvCase{envServerTLSCertFile, "cert.pem", func() { a.setServerTLSCertFile() }, func() interface{} { return a.Config.Server.TLS.CertFile }})
-		// test for setServerTLSKeyFile
-		cases = append(cases, &testEnvCase{envServerTLSKeyFile, "key.pem", func() { a.setServerTLSKeyFile() }, func() interface{} { return a.Config.Server.TLS.KeyFile }})
-		// test for setTemplateCache
-		cases = append(cases, &testEnvCase{envTemplateCache, true, func() { a.setTemplateCache() }, func() interface{} { return a.Config.Template.Cache }})
-		// test for setTemplateRoot
-		cases = append(cases, &testEnvCase{envTemplateRoot, "/to/path", func() { a.setTemplateRoot() }, func() interface{} { return a.Config.Template.Root }})
-		// test for setTemplateSuffix
-		cases = append(cases, &testEnvCase{envTemplateSuffix, ".tpl", func() { a.setTemplateSuffix() }, func() interface{} { return a.Config.Template.Suffix }})
+		// test for ServerMaxHeaderSize
+		cases = append(cases, &testEnvCase{envServerMaxHeaderSize, 100, parse, func() interface{} { return a.Config.Server.MaxHeaderSize }})
+		// test for ServerReadTimeout
+		cases = append(cases, &testEnvCase{envServerReadTimeout, "10s", parse, func() interface{} { return a.Config.Server.ReadTimeout }})
+		// test for ServerReadHeaderTimeout
+		cases = append(cases, &testEnvCase{envServerReadHeaderTimeout, "10s", parse, func() interface{} { return a.Config.Server.ReadHeaderTimeout }})
+		// test for ServerWriteTimeout
+		cases = append(cases, &testEnvCase{envServerWriteTimeout, "10s", parse, func() interface{} { return a.Config.Server.WriteTimeout }})
+		// test for ServerIdleTimeout
+		cases = append(cases, &testEnvCase{envServerIdleTimeout, "10s", parse, func() interface{} { return a.Config.Server.IdleTimeout }})
+		// test for ServerShutdownTimeout
+		cases = append(cases, &testEnvCase{envServerShutdownTimeout, "10s", parse, func() interface{} { return a.Config.Server.ShutdownTimeout }})
+		// test for ServerHTTP2MaxStreams
+		cases = append(cases, &testEnvCase{envServerHTTP2MaxStreams, uint32(100), parse, func() interface{} { return a.Config.Server.HTTP2.MaxConcurrentStreams }})
+		// test for ServerHTTP2MaxFrameSize
+		cases = append(cases, &testEnvCase{envServerHTTP2MaxFrameSize, uint32(1 << 20), parse, func() interface{} { return a.Config.Server.HTTP2.MaxReadFrameSize }})
+		// test for ServerHTTP2IdleTimeout
+		cases = append(cases, &testEnvCase{envServerHTTP2IdleTimeout, "10s", parse, func() interface{} { return a.Config.Server.HTTP2.IdleTimeout }})
+		// test for ServerHTTP2ConnBuffer
+		cases = append(cases, &testEnvCase{envServerHTTP2ConnBuffer, int32(1 << 20), parse, func() interface{} { return a.Config.Server.HTTP2.MaxUploadBufferPerConnection }})
+		// test for ServerHTTP2StreamBuffer
+		cases = append(cases, &testEnvCase{envServerHTTP2StreamBuffer, int32(1 << 16), parse, func() interface{} { return a.Config.Server.HTTP2.MaxUploadBufferPerStream }})
+		// test for ServerNetwork
+		cases = append(cases, &testEnvCase{envServerNetwork, "tcp", parse, func() interface{} { return a.Config.Server.Network }})
+		// test for ServerSocket
+		cases = append(cases, &testEnvCase{envServerSocket, "/tmp/anoweb.sock", parse, func() interface{} { return a.Config.Server.Socket }})
+		// test for ServerSocketMode
+		cases = append(cases, &testEnvCase{envServerSocketMode, "0660", parse, func() interface{} { return a.Config.Server.SocketMode }})
+		// test for ServerHost
+		cases = append(cases, &testEnvCase{envServerHost, "0.0.0.0", parse, func() interface{} { return a.Config.Server.Host }})
+		// test for ServerPort
+		cases = append(cases, &testEnvCase{envServerPort, 1080, parse, func() interface{} { return a.Config.Server.Port }})
+		// test for BannerEnable
+		cases = append(cases, &testEnvCase{envBannerEnable, true, parse, func() interface{} { return a.Config.Banner.Enable }})
+		// test for BannerType
+		cases = append(cases, &testEnvCase{envBannerType, "default", parse, func() interface{} { return a.Config.Banner.Type }})
+		// test for BannerText
+		cases = append(cases, &testEnvCase{envBannerText, "hello world -- GO GO GO", parse, func() interface{} { return a.Config.Banner.Text }})
+		// test for BannerFile
+		cases = append(cases, &testEnvCase{envBannerFile, "banner.txt", parse, func() interface{} { return a.Config.Banner.File }})
+		// test for BannerRoutes
+		cases = append(cases, &testEnvCase{envBannerRoutes, true, parse, func() interface{} { return a.Config.Banner.Routes }})
+		// test for ServerTLSEnable
+		cases = append(cases, &testEnvCase{envServerTLSEnable, true, parse, func() interface{} { return a.Config.Server.TLS.Enable }})
+		// test for ServerTLSCertFile
+		cases = append(cases, &testEnvCase{envServerTLSCertFile, "cert.pem", parse, func() interface{} { return a.Config.Server.TLS.CertFile }})
+		// test for ServerTLSKeyFile
+		cases = append(cases, &testEnvCase{envServerTLSKeyFile, "key.pem", parse, func() interface{} { return a.Config.Server.TLS.KeyFile }})
+		// test for ServerTLSHTTPPort
+		cases = append(cases, &testEnvCase{envServerTLSHTTPPort, 8080, parse, func() interface{} { return a.Config.Server.TLS.HTTPPort }})
+		// test for ServerTLSRedirect
+		cases = append(cases, &testEnvCase{envServerTLSRedirect, true, parse, func() interface{} { return a.Config.Server.TLS.Redirect }})
+		// test for ServerTLSRedirectCode
+		cases = append(cases, &testEnvCase{envServerTLSRedirectCode, 308, parse, func() interface{} { return a.Config.Server.TLS.RedirectCode }})
+		// test for ServerTLSHSTSEnable
+		cases = append(cases, &testEnvCase{envServerTLSHSTSEnable, true, parse, func() interface{} { return a.Config.Server.TLS.HSTS.Enable }})
+		// test for ServerTLSHSTSMaxAge
+		cases = append(cases, &testEnvCase{envServerTLSHSTSMaxAge, "1h", parse, func() interface{} { return a.Config.Server.TLS.HSTS.MaxAge }})
+		// test for ServerTLSHSTSSubDomains
+		cases = append(cases, &testEnvCase{envServerTLSHSTSSubDomains, true, parse, func() interface{} { return a.Config.Server.TLS.HSTS.IncludeSubDomains }})
+		// test for ServerTLSHSTSPreload
+		cases = append(cases, &testEnvCase{envServerTLSHSTSPreload, true, parse, func() interface{} { return a.Config.Server.TLS.HSTS.Preload }})
+		// test for ServerTLSWatch
+		cases = append(cases, &testEnvCase{envServerTLSWatch, true, parse, func() interface{} { return a.Config.Server.TLS.Watch }})
+		// test for ServerTLSWatchInterval
+		cases = append(cases, &testEnvCase{envServerTLSWatchInterval, "10s", parse, func() interface{} { return a.Config.Server.TLS.WatchInterval }})
+		// test for ServerTLSClientCAFile
+		cases = append(cases, &testEnvCase{envServerTLSClientCAFile, "ca.pem", parse, func() interface{} { return a.Config.Server.TLS.ClientCAFile }})
+		// test for ServerTLSClientAuth
+		cases = append(cases, &testEnvCase{envServerTLSClientAuth, "require_and_verify", parse, func() interface{} { return a.Config.Server.TLS.ClientAuth }})
+		// test for TemplateCache
+		cases = append(cases, &testEnvCase{envTemplateCache, true, parse, func() interface{} { return a.Config.Template.Cache }})
+		// test for TemplateRoot
+		cases = append(cases, &testEnvCase{envTemplateRoot, "/to/path", parse, func() interface{} { return a.Config.Template.Root }})
+		// test for TemplateSuffix
+		cases = append(cases, &testEnvCase{envTemplateSuffix, ".tpl", parse, func() interface{} { return a.Config.Template.Suffix }})
 
 	}
 
 	for _, c := range cases {
 		// set env
 		_ = os.Setenv(c.env, fmt.Sprintf("%v", c.val))
+		defer func(env string) { _ = os.Unsetenv(env) }(c.env)
 		// before call
 		c.beforeCall()
 		expectVal := c.expectCall()
@@ -88,3 +179,57 @@ func TestEnv(t *testing.T) {
 
 	}
 }
+
+func TestEnvNames(t *testing.T) {
+	fs, err := config.Fields(config.Default())
+	require.Nil(t, err)
+	names := make(map[string]bool, len(fs))
+	for _, f := range fs {
+		names[f.EnvName("")] = true
+	}
+	for _, name := range []string{envServerMaxHeaderSize, envServerHTTP2MaxStreams, envServerHTTP2ConnBuffer, envServerTLSHSTSSubDomains, envServerTLSClientCAFile, envBannerFile, envTemplateSuffix} {
+		require.True(t, names[name], name)
+	}
+}
+
+func TestEnvPrefix(t *testing.T) {
+	_ = os.Setenv(envServerHost, "127.0.0.1")
+	_ = os.Setenv(envPrefix+envServerHost, "localhost")
+	_ = os.Setenv(envPrefix+envConfigFile, "diy.yml")
+	defer func() {
+		_ = os.Unsetenv(envServerHost)
+		_ = os.Unsetenv(envPrefix + envServerHost)
+		_ = os.Unsetenv(envPrefix + envConfigFile)
+	}()
+	a := New()
+	require.Nil(t, a.parseEnv())
+	require.Equal(t, "localhost", a.Config.Server.Host)
+	require.Equal(t, "diy.yml", a.ConfigFile)
+}
+
+func TestEnvInvalid(t *testing.T) {
+	_ = os.Setenv(envServerPort, "http")
+	defer func() { _ = os.Unsetenv(envServerPort) }()
+	err := New().parseEnv()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), envServerPort)
+}
+
+func TestEnvSources(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.yml")
+	require.Nil(t, ioutil.WriteFile(file, []byte("server:\n  host: localhost\n"), 0600))
+	_ = os.Setenv(envServerPort, "8080")
+	defer func() { _ = os.Unsetenv(envServerPort) }()
+	buf := &bytes.Buffer{}
+	a := New()
+	a.logger = log.New(buf, "", 0)
+	a.ConfigFile = file
+	require.Nil(t, a.parseEnv())
+	require.Equal(t, sourceYaml, a.configSources["server.host"])
+	require.Equal(t, sourceEnv, a.configSources["server.port"])
+	require.Equal(t, sourceDefault, a.configSources["banner.type"])
+	a.printConfig()
+	require.Contains(t, buf.String(), "Config server.host = localhost (yaml)\n")
+	require.Contains(t, buf.String(), "Config server.port = 8080 (env)\n")
+	require.Contains(t, buf.String(), "Config server.tls.hsts.max_age = 8760h0m0s (default)\n")
+}
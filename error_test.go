@@ -0,0 +1,135 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anoweb
+
+import (
+	gz "compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-the-way/anoweb/context"
+	"github.com/go-the-way/anoweb/middleware"
+	"github.com/go-the-way/anoweb/middleware/external"
+	"github.com/stretchr/testify/require"
+)
+
+type _errorMiddleware struct{}
+
+func (_errorMiddleware) Handler() func(ctx *context.Context) {
+	return func(ctx *context.Context) {
+		if ctx.Request.Header.Get("X-Token") == "" {
+			ctx.SetError(context.NewHTTPError(http.StatusUnauthorized))
+			return
+		}
+		ctx.Chain()
+	}
+}
+
+func TestAppErrorHandler(t *testing.T) {
+	newApp := func() *App {
+		return New().Use(_errorMiddleware{}).
+			Get("/user", context.Handle(func(ctx *context.Context) error {
+				return context.NewHTTPError(http.StatusNotFound, "user not found").WithCode("USER_NOT_FOUND")
+			})).
+			Post("/user", context.Handle(func(ctx *context.Context) error {
+				var user struct{ Name string }
+				ctx.Bind(&user)
+				ctx.Text(user.Name)
+				return nil
+			})).
+			Get("/db", context.Handle(func(ctx *context.Context) error {
+				return errors.New("db is down")
+			}))
+	}
+	serve := func(a *App, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("X-Token", "1")
+		rec := httptest.NewRecorder()
+		a.newDispatcher().ServeHTTP(rec, req)
+		return rec
+	}
+	{
+		a := newApp().parseRouters()
+		rec := serve(a, http.MethodGet, "/user", "")
+		require.Equal(t, http.StatusNotFound, rec.Code)
+		require.Equal(t, `{"status":404,"code":"USER_NOT_FOUND","message":"user not found"}`, rec.Body.String())
+		rec = serve(a, http.MethodPost, "/user", "<xml>")
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+		require.Equal(t, `{"status":400,"message":"Invalid request body"}`, rec.Body.String())
+		rec = serve(a, http.MethodPost, "/user", `{"Name":"apple"}`)
+		require.Equal(t, "apple", rec.Body.String())
+		rec = serve(a, http.MethodGet, "/db", "")
+		require.Equal(t, http.StatusInternalServerError, rec.Code)
+		require.NotContains(t, rec.Body.String(), "db is down")
+
+		rec = httptest.NewRecorder()
+		a.newDispatcher().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/user", nil))
+		require.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+	{
+		var handled error
+		a := newApp().ErrorHandler(func(ctx *context.Context, err error) {
+			handled = err
+			he := context.AsHTTPError(err)
+			ctx.Text(he.Message)
+			ctx.Status(he.Status)
+		}).parseRouters()
+		rec := serve(a, http.MethodGet, "/db", "")
+		require.Equal(t, http.StatusInternalServerError, rec.Code)
+		require.Equal(t, "Internal Server Error", rec.Body.String())
+		require.EqualError(t, handled, "db is down")
+	}
+}
+
+func TestAppErrorInChain(t *testing.T) {
+	pr, pw, err := os.Pipe()
+	require.Nil(t, err)
+	stdout := os.Stdout
+	os.Stdout = pw
+	logger := middleware.Logger()
+	os.Stdout = stdout
+	gzip := external.Gzip()
+	gzip.MinSize = 1
+
+	a := New().Use(logger, gzip, middleware.Recovery()).
+		Get("/user", context.Handle(func(ctx *context.Context) error {
+			return context.NewHTTPError(http.StatusBadRequest, "invalid user")
+		})).
+		Get("/panic", func(ctx *context.Context) {
+			var m map[string]int
+			m["a"]++
+		}).
+		parseRouters()
+	for path, expect := range map[string]string{
+		"/user":  `{"status":400,"message":"invalid user"}`,
+		"/panic": `{"status":500,"message":"Internal Server Error"}`,
+	} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+		a.newDispatcher().ServeHTTP(rec, req)
+		require.Equal(t, "gzip", rec.Header().Get("Content-Encoding"), path)
+		zr, err := gz.NewReader(rec.Body)
+		require.Nil(t, err, path)
+		body, _ := io.ReadAll(zr)
+		require.Equal(t, expect, string(body), path)
+	}
+	_ = pw.Close()
+	logs, _ := io.ReadAll(pr)
+	require.Contains(t, string(logs), `"GET /user HTTP/1.1" 400 `)
+	require.Contains(t, string(logs), `"GET /panic HTTP/1.1" 500 `)
+}
@@ -24,6 +24,14 @@ const (
 	Location = "Location"
 	// Allow header
 	Allow = "Allow"
+	// Accept header
+	Accept = "Accept"
+	// CacheControl header
+	CacheControl = "Cache-Control"
+	// LastEventID header
+	LastEventID = "Last-Event-ID"
+	// StrictTransportSecurity header
+	StrictTransportSecurity = "Strict-Transport-Security"
 	// AccessControlAllowOrigin header
 	AccessControlAllowOrigin = "Access-Control-Allow-Origin"
 	// AccessControlAllowHeaders header
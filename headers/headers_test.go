@@ -24,6 +24,10 @@ func TestHeaders(t *testing.T) {
 	require.Equal(t, "Content-Disposition", ContentDisposition)
 	require.Equal(t, "Location", Location)
 	require.Equal(t, "Allow", Allow)
+	require.Equal(t, "Accept", Accept)
+	require.Equal(t, "Cache-Control", CacheControl)
+	require.Equal(t, "Last-Event-ID", LastEventID)
+	require.Equal(t, "Strict-Transport-Security", StrictTransportSecurity)
 	require.Equal(t, "Access-Control-Allow-Origin", AccessControlAllowOrigin)
 	require.Equal(t, "Access-Control-Allow-Headers", AccessControlAllowHeaders)
 	require.Equal(t, "Access-Control-Allow-Methods", AccessControlAllowMethods)
@@ -12,17 +12,13 @@
 package anoweb
 
 import (
-	"bytes"
-	"errors"
-	"fmt"
-	"io/ioutil"
-	"net/http"
+	"net/http/httptest"
 	"net/url"
-	"os"
-	"reflect"
+	"strings"
 	"sync/atomic"
 	"testing"
-	"time"
+
+	"github.com/stretchr/testify/require"
 )
 
 var (
@@ -41,57 +37,14 @@ type testHTTPCase struct {
 	expect  string
 }
 
+// testHTTP drives the App in-process
 func testHTTP(t *testing.T, a *App, cases ...*testHTTPCase) {
-	errCh := make(chan error, 1)
-	okCh := make(chan struct{}, 1)
-	port := nextPort()
-	go func(port int) {
-		a.Config.Server.Port = port
-		// override env server
-		_ = os.Setenv(envServerHost, "localhost")
-		// override env port
-		_ = os.Setenv(envServerPort, fmt.Sprintf("%d", a.Config.Server.Port))
-		// override env tls enable
-		_ = os.Setenv(envServerTLSEnable, "false")
-		a.Run()
-	}(port)
-	time.AfterFunc(time.Millisecond*500, func() {
-		for _, thc := range cases {
-			var buf bytes.Buffer
-			if thc.body != "" {
-				buf.WriteString(thc.body)
-			}
-			req, err := http.NewRequest(thc.method, fmt.Sprintf("http://localhost:%d%s", port, thc.reqPath), &buf)
-			if err != nil {
-				errCh <- err
-				return
-			}
-			req.Form = thc.forms
-			resp, err := http.DefaultClient.Do(req)
-			if err != nil {
-				errCh <- err
-				return
-			}
-			readAll, err := ioutil.ReadAll(resp.Body)
-			if err != nil {
-				errCh <- err
-				return
-			}
-			if reflect.DeepEqual(string(readAll), thc.expect) {
-				okCh <- struct{}{}
-			} else {
-				errCh <- errors.New("not equal")
-			}
-		}
-	})
-
-	select {
-	case <-time.After(time.Second):
-		t.Error("test fail: timeout")
-	case err := <-errCh:
-		t.Errorf("test err: %v", err)
-	case <-okCh:
-		t.Log("test ok")
+	h := a.Handler()
+	for _, thc := range cases {
+		req := httptest.NewRequest(thc.method, thc.reqPath, strings.NewReader(thc.body))
+		req.Form = thc.forms
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		require.Equal(t, thc.expect, rec.Body.String(), "%s %s", thc.method, thc.reqPath)
 	}
-
 }
@@ -0,0 +1,66 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anoweb
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/go-the-way/anoweb/headers"
+)
+
+// redirectHandler redirects plain HTTP requests to the HTTPS server, preserving path and query
+//
+// The port is taken from the bound address of server, falls back to Config.Server.Port.
+func (a *App) redirectHandler(server *http.Server) http.Handler {
+	code := a.Config.Server.TLS.RedirectCode
+	if code != http.StatusMovedPermanently && code != http.StatusPermanentRedirect {
+		code = http.StatusMovedPermanently
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		port := strconv.Itoa(a.Config.Server.Port)
+		if _, p, err := net.SplitHostPort(server.Addr); err == nil && p != "" && p != "0" {
+			port = p
+		}
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), code)
+	})
+}
+
+// hstsHandler emits the Strict-Transport-Security header on HTTPS responses
+func (a *App) hstsHandler(next http.Handler) http.Handler {
+	hsts := a.Config.Server.TLS.HSTS
+	if hsts == nil || !hsts.Enable {
+		return next
+	}
+	value := fmt.Sprintf("max-age=%d", int64(hsts.MaxAge.Seconds()))
+	if hsts.IncludeSubDomains {
+		value += "; includeSubDomains"
+	}
+	if hsts.Preload {
+		value += "; preload"
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil {
+			w.Header().Set(headers.StrictTransportSecurity, value)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
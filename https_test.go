@@ -0,0 +1,161 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anoweb
+
+import (
+	stdctx "context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-the-way/anoweb/context"
+	"github.com/go-the-way/anoweb/headers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirectHandler(t *testing.T) {
+	test := func(port int, addr string, code, expectCode int, expectLocation string) {
+		a := New()
+		a.Config.Server.Port = port
+		a.Config.Server.TLS.RedirectCode = code
+		req := httptest.NewRequest(http.MethodGet, "http://example.com:8080/a/b?c=1&d=2", nil)
+		w := httptest.NewRecorder()
+		a.redirectHandler(&http.Server{Addr: addr}).ServeHTTP(w, req)
+		require.Equal(t, expectCode, w.Code)
+		require.Equal(t, expectLocation, w.Header().Get(headers.Location))
+	}
+	test(443, "", 0, http.StatusMovedPermanently, "https://example.com/a/b?c=1&d=2")
+	test(8443, "", http.StatusPermanentRedirect, http.StatusPermanentRedirect, "https://example.com:8443/a/b?c=1&d=2")
+	test(443, "", http.StatusFound, http.StatusMovedPermanently, "https://example.com/a/b?c=1&d=2")
+	test(0, "127.0.0.1:9443", 0, http.StatusMovedPermanently, "https://example.com:9443/a/b?c=1&d=2")
+	test(8443, "127.0.0.1:443", 0, http.StatusMovedPermanently, "https://example.com/a/b?c=1&d=2")
+}
+
+func TestHSTSHandler(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	{
+		a := New()
+		require.NotNil(t, a.hstsHandler(next))
+		w := httptest.NewRecorder()
+		a.hstsHandler(next).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "https://example.com/", nil))
+		require.Equal(t, "", w.Header().Get(headers.StrictTransportSecurity))
+	}
+	{
+		a := New()
+		a.Config.Server.TLS.HSTS.Enable = true
+		a.Config.Server.TLS.HSTS.MaxAge = time.Hour
+		a.Config.Server.TLS.HSTS.IncludeSubDomains = true
+		a.Config.Server.TLS.HSTS.Preload = true
+		w := httptest.NewRecorder()
+		a.hstsHandler(next).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "https://example.com/", nil))
+		require.Equal(t, "max-age=3600; includeSubDomains; preload", w.Header().Get(headers.StrictTransportSecurity))
+		w = httptest.NewRecorder()
+		a.hstsHandler(next).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://example.com/", nil))
+		require.Equal(t, "", w.Header().Get(headers.StrictTransportSecurity))
+	}
+}
+
+func TestAppHTTPAndHTTPS(t *testing.T) {
+	_ = ioutil.WriteFile("https.crt", []byte(certFile), 0700)
+	_ = ioutil.WriteFile("https.key", []byte(keyFile), 0700)
+	defer func() {
+		_ = os.Remove("https.crt")
+		_ = os.Remove("https.key")
+	}()
+	test := func(redirect bool) {
+		port, httpPort := nextPort(), nextPort()
+		testAppEnv(port)
+		_ = os.Setenv(envServerTLSEnable, "true")
+		_ = os.Setenv(envServerTLSCertFile, "https.crt")
+		_ = os.Setenv(envServerTLSKeyFile, "https.key")
+		_ = os.Setenv(envServerTLSHTTPPort, fmt.Sprintf("%d", httpPort))
+		_ = os.Setenv(envServerTLSRedirect, fmt.Sprintf("%v", redirect))
+		_ = os.Setenv(envServerTLSRedirectCode, "308")
+		_ = os.Setenv(envServerTLSHSTSEnable, "true")
+		defer func() {
+			_ = os.Setenv(envServerTLSEnable, "false")
+			_ = os.Unsetenv(envServerTLSHTTPPort)
+			_ = os.Unsetenv(envServerTLSRedirect)
+			_ = os.Unsetenv(envServerTLSRedirectCode)
+			_ = os.Unsetenv(envServerTLSHSTSEnable)
+		}()
+		a := New().Get("/a", func(ctx *context.Context) { ctx.Text("secure") })
+		require.Nil(t, a.Start())
+		defer func() { _ = a.Shutdown(stdctx.Background()) }()
+		client := &http.Client{
+			Timeout:       time.Second,
+			Transport:     &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error { return http.ErrUseLastResponse },
+		}
+		{
+			resp, err := client.Get(fmt.Sprintf("https://localhost:%d/a", port))
+			require.Nil(t, err)
+			body, _ := ioutil.ReadAll(resp.Body)
+			require.Equal(t, "secure", string(body))
+			require.NotEmpty(t, resp.Header.Get(headers.StrictTransportSecurity))
+		}
+		{
+			resp, err := client.Get(fmt.Sprintf("http://localhost:%d/a?b=1", httpPort))
+			require.Nil(t, err)
+			if redirect {
+				require.Equal(t, http.StatusPermanentRedirect, resp.StatusCode)
+				require.Equal(t, fmt.Sprintf("https://localhost:%d/a?b=1", port), resp.Header.Get(headers.Location))
+			} else {
+				body, _ := ioutil.ReadAll(resp.Body)
+				require.Equal(t, "secure", string(body))
+				require.Empty(t, resp.Header.Get(headers.StrictTransportSecurity))
+			}
+		}
+	}
+	test(true)
+	test(false)
+}
+
+func TestAppRedirectListenerPort(t *testing.T) {
+	_ = ioutil.WriteFile("https.crt", []byte(certFile), 0700)
+	_ = ioutil.WriteFile("https.key", []byte(keyFile), 0700)
+	defer func() {
+		_ = os.Remove("https.crt")
+		_ = os.Remove("https.key")
+	}()
+	port, httpPort := nextPort(), nextPort()
+	testAppEnv(port)
+	_ = os.Setenv(envServerTLSEnable, "true")
+	_ = os.Setenv(envServerTLSCertFile, "https.crt")
+	_ = os.Setenv(envServerTLSKeyFile, "https.key")
+	_ = os.Setenv(envServerTLSHTTPPort, fmt.Sprintf("%d", httpPort))
+	_ = os.Setenv(envServerTLSRedirect, "true")
+	defer func() {
+		_ = os.Setenv(envServerTLSEnable, "false")
+		_ = os.Unsetenv(envServerTLSHTTPPort)
+		_ = os.Unsetenv(envServerTLSRedirect)
+	}()
+	ln, err := net.Listen("tcp", "localhost:0")
+	require.Nil(t, err)
+	a := New()
+	require.Nil(t, a.start(ln))
+	defer func() { _ = a.Shutdown(stdctx.Background()) }()
+	client := &http.Client{
+		Timeout:       time.Second,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	resp, err := client.Get(fmt.Sprintf("http://localhost:%d/a", httpPort))
+	require.Nil(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, fmt.Sprintf("https://localhost:%d/a", ln.Addr().(*net.TCPAddr).Port), resp.Header.Get(headers.Location))
+}
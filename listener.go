@@ -0,0 +1,100 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anoweb
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	envListenPid = "LISTEN_PID"
+	envListenFds = "LISTEN_FDS"
+)
+
+// listenFdsStart the first file descriptor passed by systemd socket activation
+var listenFdsStart = 3
+
+func (a *App) listen() (net.Listener, error) {
+	ln, err := systemdListener()
+	if ln != nil || err != nil {
+		return ln, err
+	}
+	sc := a.Config.Server
+	switch sc.Network {
+	case "", "tcp", "tcp4", "tcp6":
+		network := sc.Network
+		if network == "" {
+			network = "tcp"
+		}
+		return net.Listen(network, sc.Host+":"+strconv.Itoa(sc.Port))
+	case "unix":
+		return unixListener(sc.Socket, sc.SocketMode)
+	default:
+		return nil, fmt.Errorf("anoweb: network not supported : %s", sc.Network)
+	}
+}
+
+// systemdListener return the first listener passed by systemd socket activation, nil if not activated
+func systemdListener() (net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv(envListenPid))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	fds, err := strconv.Atoi(os.Getenv(envListenFds))
+	if err != nil || fds < 1 {
+		return nil, nil
+	}
+	_ = os.Unsetenv(envListenPid)
+	_ = os.Unsetenv(envListenFds)
+	file := os.NewFile(uintptr(listenFdsStart), "LISTEN_FD_"+strconv.Itoa(listenFdsStart))
+	defer func() { _ = file.Close() }()
+	return net.FileListener(file)
+}
+
+// unixListener listen on the unix socket, removes the stale socket file left by a dead process
+func unixListener(socket, mode string) (net.Listener, error) {
+	if socket == "" {
+		return nil, errors.New("anoweb: unix socket path is empty")
+	}
+	if fi, err := os.Stat(socket); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("anoweb: %s exists and is not a socket", socket)
+		}
+		if conn, err := net.DialTimeout("unix", socket, time.Second); err == nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("anoweb: unix socket %s is in use", socket)
+		}
+		if err = os.Remove(socket); err != nil {
+			return nil, err
+		}
+	}
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, err
+	}
+	if mode != "" {
+		perm, err := strconv.ParseUint(mode, 8, 32)
+		if err == nil {
+			err = os.Chmod(socket, os.FileMode(perm))
+		}
+		if err != nil {
+			_ = ln.Close()
+			return nil, err
+		}
+	}
+	return ln, nil
+}
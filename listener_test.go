@@ -0,0 +1,117 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anoweb
+
+import (
+	stdctx "context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-the-way/anoweb/context"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppServe(t *testing.T) {
+	testAppEnv(nextPort())
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	a := New().Get("/", func(ctx *context.Context) { ctx.Text("served") })
+	errCh := make(chan error, 1)
+	go func() { errCh <- a.Serve(ln) }()
+	resp, err := http.Get(fmt.Sprintf("http://%s/", ln.Addr().String()))
+	require.Nil(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	require.Equal(t, "served", string(body))
+	require.Nil(t, a.Shutdown(stdctx.Background()))
+	require.Nil(t, <-errCh)
+}
+
+func TestAppListenTCP(t *testing.T) {
+	a := New()
+	a.Config.Server.Host = "127.0.0.1"
+	a.Config.Server.Port = 0
+	ln, err := a.listen()
+	require.Nil(t, err)
+	require.Equal(t, "tcp", ln.Addr().Network())
+	_ = ln.Close()
+	a.Config.Server.Network = "udp"
+	_, err = a.listen()
+	require.NotNil(t, err)
+}
+
+func TestAppListenUnix(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "a.sock")
+	a := New()
+	a.Config.Server.Network = "unix"
+	a.Config.Server.Socket = socket
+	a.Config.Server.SocketMode = "0600"
+	// stale socket file
+	{
+		stale, err := net.Listen("unix", socket)
+		require.Nil(t, err)
+		stale.(*net.UnixListener).SetUnlinkOnClose(false)
+		_ = stale.Close()
+		_, err = os.Stat(socket)
+		require.Nil(t, err)
+	}
+	ln, err := a.listen()
+	require.Nil(t, err)
+	fi, err := os.Stat(socket)
+	require.Nil(t, err)
+	require.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+	// socket in use
+	{
+		_, err = a.listen()
+		require.NotNil(t, err)
+	}
+	_ = ln.Close()
+	_, err = os.Stat(socket)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestAppListenUnixNotSocket(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.txt")
+	require.Nil(t, ioutil.WriteFile(file, []byte("hello"), 0600))
+	_, err := unixListener(file, "")
+	require.NotNil(t, err)
+	_, err = unixListener("", "")
+	require.NotNil(t, err)
+}
+
+func TestAppServeUnix(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "a.sock")
+	testAppEnv(nextPort())
+	_ = os.Setenv(envServerNetwork, "unix")
+	_ = os.Setenv(envServerSocket, socket)
+	defer func() {
+		_ = os.Unsetenv(envServerNetwork)
+		_ = os.Unsetenv(envServerSocket)
+	}()
+	a := New().Get("/", func(ctx *context.Context) { ctx.Text("unix") })
+	require.Nil(t, a.Start())
+	defer func() { _ = a.Shutdown(stdctx.Background()) }()
+	client := &http.Client{Timeout: time.Second, Transport: &http.Transport{
+		DialContext: func(ctx stdctx.Context, _, _ string) (net.Conn, error) {
+			return net.Dial("unix", socket)
+		},
+	}}
+	resp, err := client.Get("http://unix/")
+	require.Nil(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	require.Equal(t, "unix", string(body))
+}
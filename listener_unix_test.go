@@ -0,0 +1,52 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package anoweb
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemdListener(t *testing.T) {
+	{
+		ln, err := systemdListener()
+		require.Nil(t, ln)
+		require.Nil(t, err)
+	}
+	tcpLn, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer func() { _ = tcpLn.Close() }()
+	file, err := tcpLn.(*net.TCPListener).File()
+	require.Nil(t, err)
+	// systemdListener owns the passed fd and closes it, so pass a dup not owned by file
+	fd, err := syscall.Dup(int(file.Fd()))
+	require.Nil(t, err)
+	_ = file.Close()
+	start := listenFdsStart
+	listenFdsStart = fd
+	defer func() { listenFdsStart = start }()
+	_ = os.Setenv(envListenPid, fmt.Sprintf("%d", os.Getpid()))
+	_ = os.Setenv(envListenFds, "1")
+	ln, err := systemdListener()
+	require.Nil(t, err)
+	require.Equal(t, tcpLn.Addr().String(), ln.Addr().String())
+	require.Equal(t, "", os.Getenv(envListenPid))
+	_ = ln.Close()
+}
@@ -73,6 +73,8 @@ func (a *App) UseLogger() *App {
 }
 
 // RecoveryConfig Sets Recovery config
+//
+// Deprecated: the recovered panics are responded by the error handler unless configured, format them with ErrorHandler instead
 func (a *App) RecoveryConfig(codeName string, codeVal int, msgName string) *App {
 	a.defaultMWState.recoveryCodeName = codeName
 	a.defaultMWState.recoveryCodeVal = codeVal
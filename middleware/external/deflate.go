@@ -14,6 +14,7 @@ package external
 import (
 	"bytes"
 	"compress/flate"
+	"io"
 	"strings"
 
 	"github.com/go-the-way/anoweb/context"
@@ -44,6 +45,15 @@ func Deflate() *deflate {
 	}
 }
 
+func (d *deflate) compressible(contentType string) bool {
+	for _, ct := range d.ContentType {
+		if strings.HasPrefix(contentType, ct) {
+			return true
+		}
+	}
+	return false
+}
+
 func (d *deflate) accept(ctx *context.Context) bool {
 	acceptEncoding := ctx.Request.Header.Get("Accept-Encoding")
 	return strings.Contains(acceptEncoding, "deflate")
@@ -51,8 +61,24 @@ func (d *deflate) accept(ctx *context.Context) bool {
 
 func (d *deflate) Handler() func(ctx *context.Context) {
 	return func(ctx *context.Context) {
-		ctx.Chain()
 		if !d.accept(ctx) {
+			ctx.Chain()
+			return
+		}
+		ctx.Writer().OnCommit(func(w *context.ResponseWriter) {
+			if !d.compressible(ctx.Response.ContentType) {
+				return
+			}
+			w.Header().Set("Vary", "Content-Encoding")
+			w.Header().Set("Content-Encoding", "deflate")
+			w.Header().Del("Content-Length")
+			w.Filter(func(out io.Writer) io.WriteCloser {
+				fw, _ := flate.NewWriter(out, d.Level)
+				return fw
+			})
+		})
+		ctx.Chain()
+		if ctx.Committed() {
 			return
 		}
 		r := ctx.Response
@@ -15,6 +15,8 @@ import (
 	"bytes"
 	"compress/flate"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/go-the-way/anoweb/config"
@@ -70,3 +72,21 @@ func TestDeflateWithoutDeflate(t *testing.T) {
 	require.Equal(t, "", ctx.Response.Header.Get("Vary"))
 	require.Equal(t, "", ctx.Response.Header.Get("Content-Encoding"))
 }
+
+func TestDeflateStream(t *testing.T) {
+	data := `0123456789 0123456789`
+	rec := httptest.NewRecorder()
+	ctx := context.New()
+	ctx.Allocate(buildReq(false), &config.Template{})
+	ctx.SetResponseWriter(rec)
+	ctx.Add(Deflate().Handler())
+	ctx.Add(func(ctx *context.Context) {
+		_ = ctx.Stream(strings.NewReader(data))
+	})
+	ctx.Chain()
+	require.Nil(t, ctx.Writer().Close())
+	require.Equal(t, "deflate", rec.Header().Get("Content-Encoding"))
+	var buf bytes.Buffer
+	_, _ = buf.ReadFrom(flate.NewReader(rec.Body))
+	require.Equal(t, data, buf.String())
+}
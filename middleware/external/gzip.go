@@ -14,6 +14,7 @@ package external
 import (
 	"bytes"
 	gz "compress/gzip"
+	"io"
 	"strings"
 
 	"github.com/go-the-way/anoweb/context"
@@ -44,6 +45,15 @@ func Gzip() *gzip {
 	}
 }
 
+func (g *gzip) compressible(contentType string) bool {
+	for _, ct := range g.ContentType {
+		if strings.HasPrefix(contentType, ct) {
+			return true
+		}
+	}
+	return false
+}
+
 func (g *gzip) accept(ctx *context.Context) bool {
 	acceptEncoding := ctx.Request.Header.Get("Accept-Encoding")
 	return strings.Contains(acceptEncoding, "gzip")
@@ -51,8 +61,24 @@ func (g *gzip) accept(ctx *context.Context) bool {
 
 func (g *gzip) Handler() func(c *context.Context) {
 	return func(ctx *context.Context) {
-		ctx.Chain()
 		if !g.accept(ctx) {
+			ctx.Chain()
+			return
+		}
+		ctx.Writer().OnCommit(func(w *context.ResponseWriter) {
+			if !g.compressible(ctx.Response.ContentType) {
+				return
+			}
+			w.Header().Set("Vary", "Content-Encoding")
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Header().Del("Content-Length")
+			w.Filter(func(out io.Writer) io.WriteCloser {
+				gw, _ := gz.NewWriterLevel(out, g.Level)
+				return gw
+			})
+		})
+		ctx.Chain()
+		if ctx.Committed() {
 			return
 		}
 		r := ctx.Response
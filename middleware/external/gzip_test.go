@@ -14,10 +14,13 @@ package external
 import (
 	"bytes"
 	gz "compress/gzip"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/go-the-way/anoweb/config"
 	"github.com/go-the-way/anoweb/context"
+	"github.com/go-the-way/anoweb/mime"
 
 	"github.com/stretchr/testify/require"
 )
@@ -59,3 +62,33 @@ func TestGzipWithoutGzip(t *testing.T) {
 	require.Equal(t, "", ctx.Response.Header.Get("Vary"))
 	require.Equal(t, "", ctx.Response.Header.Get("Content-Encoding"))
 }
+
+func TestGzipStream(t *testing.T) {
+	data := `0123456789 0123456789`
+	for _, contentType := range []string{mime.TEXT, "image/png"} {
+		rec := httptest.NewRecorder()
+		ctx := context.New()
+		ctx.Allocate(buildReq(false), &config.Template{})
+		ctx.SetResponseWriter(rec)
+		ctx.Add(Gzip().Handler())
+		ctx.Add(func(ctx *context.Context) {
+			ctx.Response.ContentType = contentType
+			_ = ctx.Stream(strings.NewReader(data))
+		})
+		ctx.Chain()
+		require.Nil(t, ctx.Writer().Close())
+		require.Equal(t, int64(len(data)), ctx.Writer().Written())
+		if contentType != mime.TEXT {
+			require.Equal(t, "", rec.Header().Get("Content-Encoding"))
+			require.Equal(t, data, rec.Body.String())
+			continue
+		}
+		require.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+		rd, err := gz.NewReader(rec.Body)
+		require.Nil(t, err)
+		var buf bytes.Buffer
+		_, err = buf.ReadFrom(rd)
+		require.Nil(t, err)
+		require.Equal(t, data, buf.String())
+	}
+}
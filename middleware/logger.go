@@ -40,6 +40,10 @@ func (l *logger) Handler() func(ctx *context.Context) {
 		r := ctx.Response
 		end := time.Now().UnixNano()
 		req := ctx.Request
-		l.logger.Println(fmt.Sprintf("\"%s\" \"%s %s %s\" %d %d %dms \"%s\"", req.RemoteAddr, req.Method, req.URL.RequestURI(), req.Proto, r.Status, len(r.Data), (end-start)/1000.0/1000.0, req.UserAgent()))
+		size := int64(len(r.Data))
+		if ctx.Committed() {
+			size = ctx.Writer().Written()
+		}
+		l.logger.Println(fmt.Sprintf("\"%s\" \"%s %s %s\" %d %d %dms \"%s\"", req.RemoteAddr, req.Method, req.URL.RequestURI(), req.Proto, r.Status, size, (end-start)/1000.0/1000.0, req.UserAgent()))
 	}
 }
@@ -13,11 +13,14 @@ package middleware
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
 	"github.com/go-the-way/anoweb/config"
 	"github.com/go-the-way/anoweb/context"
+
+	"github.com/stretchr/testify/require"
 )
 
 func TestLogger(t *testing.T) {
@@ -31,3 +34,19 @@ func TestLogger(t *testing.T) {
 	ctx.Add(func(ctx *context.Context) {})
 	ctx.Chain()
 }
+
+func TestLoggerStream(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	h := Logger()
+	var buf strings.Builder
+	h.logger.SetOutput(&buf)
+	ctx := context.New()
+	ctx.Allocate(req, &config.Template{})
+	ctx.SetResponseWriter(httptest.NewRecorder())
+	ctx.Add(h.Handler())
+	ctx.Add(func(ctx *context.Context) {
+		_ = ctx.Stream(strings.NewReader("hello"))
+	})
+	ctx.Chain()
+	require.Contains(t, buf.String(), " 200 5 ")
+}
@@ -12,8 +12,11 @@
 package middleware
 
 import (
+	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
+	"os"
 	"runtime"
 
 	"github.com/go-the-way/anoweb/context"
@@ -24,44 +27,54 @@ type recovery struct {
 	handler func(ctx *context.Context)
 }
 
-// Recovery return new recovery
+var recoveryLogger = log.New(os.Stderr, "[Recovery] ", log.LstdFlags)
+
+// Recovery return new recovery, the recovered panics are logged with the stack,
+// and responded as 500 by the error handler, see App.ErrorHandler
 func Recovery(handlers ...func(ctx *context.Context)) Middleware {
-	return RecoveryWithConfig("code", 500, "message", handlers...)
+	if len(handlers) > 0 && handlers[0] != nil {
+		return &recovery{handlers[0]}
+	}
+	return &recovery{recoverWith(func(ctx *context.Context, err error) {
+		ctx.SetError(context.NewHTTPError(http.StatusInternalServerError).Wrap(err))
+	})}
 }
 
-// RecoveryWithConfig return new recovery
+// RecoveryWithConfig return new recovery, the recovered panics are logged with the stack,
+// and responded as 500 with the JSON `{"<codeName>":<codeVal>,"<msgName>":"<panic message>"}`
+//
+// Deprecated: use Recovery, and format the responses with App.ErrorHandler instead
 func RecoveryWithConfig(codeName string, codeVal int, msgName string, handlers ...func(ctx *context.Context)) Middleware {
-	var handler func(ctx *context.Context)
 	if len(handlers) > 0 && handlers[0] != nil {
-		handler = handlers[0]
-	} else {
-		handler = func(ctx *context.Context) {
-			defer func() {
-				if re := recover(); re != nil {
-					_, _ = fmt.Println(fmt.Sprintf("Recovered: %v", re))
-					var buf [4096]byte
-					n := runtime.Stack(buf[:], false)
-					fmt.Printf("%s\n", string(buf[:n]))
-					message := ""
-					switch re.(type) {
-					case string:
-						message = fmt.Sprintf("%v", re)
-					case error:
-						message = re.(error).Error()
-					default:
-						message = fmt.Sprintf("%v", re)
-					}
-					ctx.Write(context.Builder().
-						Data([]byte(fmt.Sprintf(`{"%s":%d,"%s":"%s"}`, codeName, codeVal, msgName, message))).
-						ContentType(mime.JSON).
-						Status(http.StatusInternalServerError).
-						Build())
+		return &recovery{handlers[0]}
+	}
+	return &recovery{recoverWith(func(ctx *context.Context, err error) {
+		message, _ := json.Marshal(err.Error())
+		ctx.Write(context.Builder().
+			Data([]byte(fmt.Sprintf(`{"%s":%d,"%s":%s}`, codeName, codeVal, msgName, message))).
+			ContentType(mime.JSON).
+			Status(http.StatusInternalServerError).
+			Build())
+	})}
+}
+
+// recoverWith return the handler recovering the panics of the chain, which are logged and responded by respond
+func recoverWith(respond func(ctx *context.Context, err error)) func(ctx *context.Context) {
+	return func(ctx *context.Context) {
+		defer func() {
+			if re := recover(); re != nil {
+				var buf [4096]byte
+				n := runtime.Stack(buf[:], false)
+				recoveryLogger.Printf("Recovered: %v\n%s", re, buf[:n])
+				err, ok := re.(error)
+				if !ok {
+					err = fmt.Errorf("%v", re)
 				}
-			}()
-			ctx.Chain()
-		}
+				respond(ctx, err)
+			}
+		}()
+		ctx.Chain()
 	}
-	return &recovery{handler}
 }
 
 // Handler implements
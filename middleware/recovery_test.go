@@ -18,6 +18,7 @@ import (
 
 	"github.com/go-the-way/anoweb/config"
 	"github.com/go-the-way/anoweb/context"
+	"github.com/go-the-way/anoweb/mime"
 
 	"github.com/stretchr/testify/require"
 )
@@ -67,5 +68,20 @@ func TestRecoveryDefault(t *testing.T) {
 		ctx.Add(r.Handler())
 		ctx.Add(func(ctx *context.Context) { panic(100) })
 		ctx.Chain()
+		require.Equal(t, http.StatusInternalServerError, context.AsHTTPError(ctx.LastError()).Status)
+		require.EqualError(t, errors.Unwrap(ctx.LastError()), "100")
 	}
 }
+
+func TestRecoveryWithConfig(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	ctx := context.New()
+	ctx.Allocate(req, &config.Template{})
+	ctx.SetErrorHandler(context.DefaultErrorHandler)
+	ctx.Add(RecoveryWithConfig("errcode", 5000, "msg").Handler())
+	ctx.Add(func(ctx *context.Context) { panic(`try "panic"`) })
+	ctx.Chain()
+	require.Equal(t, http.StatusInternalServerError, ctx.Response.Status)
+	require.Equal(t, mime.JSON, ctx.Response.ContentType)
+	require.Equal(t, `{"errcode":5000,"msg":"try \"panic\""}`, string(ctx.Response.Data))
+}
@@ -0,0 +1,62 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	stdctx "context"
+	"net/http"
+	"time"
+
+	"github.com/go-the-way/anoweb/context"
+)
+
+type timeout struct {
+	timeout time.Duration
+	status  int
+	message string
+}
+
+// Timeout return new timeout, responds 503 when the handlers exceed the timeout
+func Timeout(d time.Duration) Middleware {
+	return TimeoutWithConfig(d, http.StatusServiceUnavailable, "")
+}
+
+// TimeoutWithConfig return new timeout, responds the status(e.g. 504) and the message when the handlers exceed the timeout.
+//
+// The handlers observe the deadline by ctx.Done and are expected to return early,
+// the response written by them is dropped and the context.HTTPError of the status is set by SetError,
+// which is responded by the error handler, unless the response is committed already, e.g. by streaming.
+// The headers and the cookies set before the timeout middleware are kept.
+func TimeoutWithConfig(d time.Duration, status int, message string) Middleware {
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	return &timeout{d, status, message}
+}
+
+// Handler implements
+func (t *timeout) Handler() func(ctx *context.Context) {
+	return func(ctx *context.Context) {
+		parent := ctx.Request.Context()
+		tctx, cancel := stdctx.WithTimeout(parent, t.timeout)
+		defer cancel()
+		header, cookies := ctx.Response.Header.Clone(), append([]*http.Cookie(nil), ctx.Response.Cookies...)
+		ctx.SetStd(tctx)
+		ctx.Chain()
+		ctx.SetStd(parent)
+		if tctx.Err() == stdctx.DeadlineExceeded && parent.Err() == nil && !ctx.Committed() {
+			ctx.Response.Header, ctx.Response.Cookies = header, cookies
+			ctx.Response.Data, ctx.Response.ContentType, ctx.Response.Status = nil, "", t.status
+			ctx.SetError(context.NewHTTPError(t.status, t.message))
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	stdctx "context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-the-way/anoweb/context"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testTimeout(m Middleware, req *http.Request, handler func(ctx *context.Context)) *context.Context {
+	ctx := context.New()
+	ctx.Allocate(req, nil)
+	ctx.SetResponseWriter(httptest.NewRecorder())
+	ctx.SetErrorHandler(context.DefaultErrorHandler)
+	ctx.Add(m.Handler())
+	ctx.Add(handler)
+	ctx.Chain()
+	return ctx
+}
+
+func TestTimeout(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	{
+		ctx := testTimeout(Timeout(time.Second), req, func(ctx *context.Context) {
+			_, ok := ctx.Deadline()
+			require.True(t, ok)
+			ctx.Text("done")
+		})
+		require.Equal(t, http.StatusOK, ctx.Response.Status)
+		require.Equal(t, "done", string(ctx.Response.Data))
+	}
+	{
+		ctx := testTimeout(Timeout(10*time.Millisecond), req, func(ctx *context.Context) {
+			ctx.Response.Header.Set("X-Partial", "1")
+			<-ctx.Done()
+			ctx.Text("late")
+		})
+		require.Equal(t, http.StatusServiceUnavailable, ctx.Response.Status)
+		require.Equal(t, `{"status":503,"message":"Service Unavailable"}`, string(ctx.Response.Data))
+		require.Equal(t, "", ctx.Response.Header.Get("X-Partial"))
+		require.Equal(t, http.StatusServiceUnavailable, context.AsHTTPError(ctx.LastError()).Status)
+	}
+	{
+		ctx := testTimeout(TimeoutWithConfig(10*time.Millisecond, http.StatusGatewayTimeout, "too slow"), req, func(ctx *context.Context) {
+			time.Sleep(20 * time.Millisecond)
+			ctx.Text("ignored the deadline")
+		})
+		require.Equal(t, http.StatusGatewayTimeout, ctx.Response.Status)
+		require.Equal(t, `{"status":504,"message":"too slow"}`, string(ctx.Response.Data))
+	}
+}
+
+func TestTimeoutKeepsOuterResponse(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	ctx := context.New()
+	ctx.Allocate(req, nil)
+	ctx.SetResponseWriter(httptest.NewRecorder())
+	ctx.SetErrorHandler(context.DefaultErrorHandler)
+	ctx.Add(func(ctx *context.Context) {
+		ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")
+		ctx.Response.Cookies = append(ctx.Response.Cookies, &http.Cookie{Name: "session", Value: "1"})
+		ctx.Chain()
+		_, ok := ctx.Deadline()
+		require.False(t, ok)
+		require.Nil(t, ctx.Err())
+	})
+	ctx.Add(Timeout(10 * time.Millisecond).Handler())
+	ctx.Add(func(ctx *context.Context) {
+		ctx.Response.Header.Set("X-Partial", "1")
+		<-ctx.Done()
+	})
+	ctx.Chain()
+	require.Equal(t, http.StatusServiceUnavailable, ctx.Response.Status)
+	require.Equal(t, "*", ctx.Response.Header.Get("Access-Control-Allow-Origin"))
+	require.Equal(t, "", ctx.Response.Header.Get("X-Partial"))
+	require.Len(t, ctx.Response.Cookies, 1)
+}
+
+func TestTimeoutCommitted(t *testing.T) {
+	ctx := testTimeout(Timeout(10*time.Millisecond), httptest.NewRequest(http.MethodGet, "/", nil), func(ctx *context.Context) {
+		_ = ctx.Stream(strings.NewReader("streamed"))
+		<-ctx.Done()
+	})
+	require.True(t, ctx.Committed())
+	require.Equal(t, http.StatusOK, ctx.Response.Status)
+}
+
+func TestTimeoutClientGone(t *testing.T) {
+	reqCtx, cancel := stdctx.WithCancel(stdctx.Background())
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(reqCtx)
+	ctx := testTimeout(Timeout(time.Second), req, func(ctx *context.Context) {
+		cancel()
+		<-ctx.Done()
+		ctx.Text("gone")
+	})
+	require.Equal(t, stdctx.Canceled, ctx.Err())
+	require.Equal(t, "gone", string(ctx.Response.Data))
+}
@@ -14,11 +14,17 @@ package anoweb
 import (
 	"embed"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/go-the-way/anoweb/context"
+	"github.com/go-the-way/anoweb/middleware"
+	"github.com/go-the-way/anoweb/mime"
+	"github.com/go-the-way/anoweb/router"
 	"github.com/go-the-way/anoweb/session"
 	"github.com/go-the-way/anoweb/session/memory"
+	"github.com/stretchr/testify/require"
 )
 
 var (
@@ -146,4 +152,133 @@ func TestAppUses(t *testing.T) {
 			RecoveryConfig("code", 5000, "message").
 			useDefaultMWs()
 	}
+	{
+		a := New().UseRecovery().RecoveryConfig("code", 5000, "message").Get("/", func(ctx *context.Context) { panic("boom") })
+		rec := httptest.NewRecorder()
+		a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Equal(t, http.StatusInternalServerError, rec.Code)
+		require.Equal(t, `{"code":5000,"message":"boom"}`, rec.Body.String())
+	}
+}
+
+func TestMiddlewareTimeout(t *testing.T) {
+	a := New().Use(middleware.Timeout(10*time.Millisecond)).Get("/", func(ctx *context.Context) {
+		if ctx.Param("wait") != "" {
+			<-ctx.Done()
+			return
+		}
+		ctx.Text("done")
+	}).parseRouters()
+	d := a.newDispatcher()
+	{
+		rec := httptest.NewRecorder()
+		d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?wait=1", nil))
+		require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	}
+	{
+		rec := httptest.NewRecorder()
+		d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "done", rec.Body.String())
+	}
+}
+
+type _abortMiddleware struct {
+	statuses *[]int
+}
+
+func (m _abortMiddleware) Handler() func(ctx *context.Context) {
+	return func(ctx *context.Context) {
+		ctx.Next()
+		*m.statuses = append(*m.statuses, ctx.Response.Status)
+	}
+}
+
+type _authMiddleware struct{}
+
+func (_authMiddleware) Handler() func(ctx *context.Context) {
+	return func(ctx *context.Context) {
+		if ctx.Param("token") == "" {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		}
+		ctx.Next()
+	}
+}
+
+func TestMiddlewareAbort(t *testing.T) {
+	var statuses []int
+	a := New().Use(_abortMiddleware{&statuses}, _authMiddleware{}).Get("/", func(ctx *context.Context) {
+		ctx.Text("ok")
+	}).parseRouters()
+	d := a.newDispatcher()
+	rec := httptest.NewRecorder()
+	d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.Equal(t, `{"error":"unauthorized"}`, rec.Body.String())
+	rec = httptest.NewRecorder()
+	d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?token=1", nil))
+	require.Equal(t, "ok", rec.Body.String())
+	require.Equal(t, []int{http.StatusUnauthorized, http.StatusOK}, statuses)
+}
+
+type _traceMiddleware struct {
+	trace *[]string
+	name  string
+}
+
+func (m _traceMiddleware) Handler() func(ctx *context.Context) {
+	return func(ctx *context.Context) {
+		*m.trace = append(*m.trace, m.name)
+		ctx.Next()
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var trace []string
+	mw := func(name string) _traceMiddleware { return _traceMiddleware{&trace, name} }
+	handler := func(ctx *context.Context) {
+		trace = append(trace, "handler")
+		ctx.Text("ok")
+	}
+	r := router.NewRouter().Use(mw("router")).
+		Get("/users/{id}", handler, mw("route")).
+		Mount("/debug", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { trace = append(trace, "mount") }))
+	a := New().Use(mw("global")).
+		AddRouterGroup(router.NewGroup("/admin").Use(mw("group")).Add(r)).
+		AddRouter(router.NewRouter().Use(mw("router")).Get("/", handler)).
+		Get("/plain", handler).
+		Resource("/res", "context/testdata/file.txt", mime.TEXT, mw("resource")).
+		parseRouters()
+	d := a.newDispatcher()
+	for path, expect := range map[string][]string{
+		"/admin/users/1":    {"global", "group", "router", "route", "handler"},
+		"/admin/debug/vars": {"global", "group", "router", "mount"},
+		"/":                 {"global", "router", "handler"},
+		"/plain":            {"global", "handler"},
+		"/res":              {"global", "resource"},
+	} {
+		trace = nil
+		d.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, expect, trace, path)
+	}
+}
+
+func TestMiddlewareRouteAbort(t *testing.T) {
+	var statuses []int
+	a := New().Use(_abortMiddleware{&statuses}).
+		Get("/", func(ctx *context.Context) { ctx.Text("ok") }, _authMiddleware{}).
+		Get("/public", func(ctx *context.Context) { ctx.Text("public") }).
+		parseRouters()
+	d := a.newDispatcher()
+	rec := httptest.NewRecorder()
+	d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.Equal(t, `{"error":"unauthorized"}`, rec.Body.String())
+	rec = httptest.NewRecorder()
+	d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?token=1", nil))
+	require.Equal(t, "ok", rec.Body.String())
+	rec = httptest.NewRecorder()
+	d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/public", nil))
+	require.Equal(t, "public", rec.Body.String())
+	require.Equal(t, []int{http.StatusUnauthorized, http.StatusOK, http.StatusOK}, statuses)
 }
@@ -24,6 +24,10 @@ const (
 	JSON = "application/json;charset=utf-8"
 	// XML  MIME
 	XML = "application/xml;charset=utf-8"
+	// SSE  MIME
+	SSE = "text/event-stream;charset=utf-8"
+	// ProblemJSON MIME, RFC 7807
+	ProblemJSON = "application/problem+json;charset=utf-8"
 
 	// BMP  MIME
 	BMP = "image/bmp"
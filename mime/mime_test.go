@@ -25,6 +25,8 @@ func TestMime(t *testing.T) {
 		{CSS, "text/css;charset=utf-8"},
 		{JSON, "application/json;charset=utf-8"},
 		{XML, "application/xml;charset=utf-8"},
+		{SSE, "text/event-stream;charset=utf-8"},
+		{ProblemJSON, "application/problem+json;charset=utf-8"},
 		{BMP, "image/bmp"},
 		{JPG, "image/jpg"},
 		{PNG, "image/png"},
@@ -16,33 +16,49 @@ import (
 	"net/http"
 
 	"github.com/go-the-way/anoweb/rest"
+	"github.com/go-the-way/anoweb/router"
 	"github.com/go-the-way/anoweb/util"
 )
 
 // Controller Route REST-ful Controller
 func (a *App) Controller(c ...rest.Controller) *App {
+	site := router.Caller()
+	for range c {
+		a.controllerSites = append(a.controllerSites, site)
+	}
 	a.controllers = append(a.controllers, c...)
 	return a
 }
 
 func (a *App) routeRestControllers() *App {
-	for _, c := range a.controllers {
+	for i, c := range a.controllers {
+		r := router.NewRouter()
+		if mc, ok := c.(rest.MiddlewareController); ok {
+			r.Use(mc.Middlewares()...)
+		}
 		prefix := util.TrimSpecialChars(c.Prefix())
 		if c.Get() != nil {
-			a.Route(http.MethodGet, fmt.Sprintf("%s/{RESTFUL_KEY}", prefix), c.Get())
+			r.Route(http.MethodGet, fmt.Sprintf("%s/{RESTFUL_KEY}", prefix), c.Get())
 		}
 		if c.Gets() != nil {
-			a.Route(http.MethodGet, prefix, c.Gets())
+			r.Route(http.MethodGet, prefix, c.Gets())
 		}
 		if c.Post() != nil {
-			a.Route(http.MethodPost, prefix, c.Post())
+			r.Route(http.MethodPost, prefix, c.Post())
 		}
 		if c.Put() != nil {
-			a.Route(http.MethodPut, fmt.Sprintf("%s/{RESTFUL_KEY}", prefix), c.Put())
+			r.Route(http.MethodPut, fmt.Sprintf("%s/{RESTFUL_KEY}", prefix), c.Put())
 		}
 		if c.Delete() != nil {
-			a.Route(http.MethodDelete, fmt.Sprintf("%s/{RESTFUL_KEY}", prefix), c.Delete())
+			r.Route(http.MethodDelete, fmt.Sprintf("%s/{RESTFUL_KEY}", prefix), c.Delete())
+		}
+		for _, s := range r.Simples {
+			r.Registered(s).Site = a.controllerSites[i]
+		}
+		for _, d := range r.Dynamics {
+			r.Registered(d.Simple).Site = a.controllerSites[i]
 		}
+		a.AddRouter(r)
 	}
 	return a
 }
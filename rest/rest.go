@@ -11,7 +11,10 @@
 
 package rest
 
-import "github.com/go-the-way/anoweb/context"
+import (
+	"github.com/go-the-way/anoweb/context"
+	"github.com/go-the-way/anoweb/middleware"
+)
 
 // Controller interface
 type Controller interface {
@@ -28,3 +31,10 @@ type Controller interface {
 	// Delete route => DELETE: /${Prefix}/${RESTFUL_KEY}
 	Delete() func(ctx *context.Context)
 }
+
+// MiddlewareController the Controller with the middlewares of its routes, run after the global ones
+type MiddlewareController interface {
+	Controller
+	// Middlewares the middlewares of the controller routes
+	Middlewares() []middleware.Middleware
+}
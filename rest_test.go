@@ -13,7 +13,10 @@ package anoweb
 
 import (
 	"github.com/go-the-way/anoweb/context"
+	"github.com/go-the-way/anoweb/middleware"
 	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -50,3 +53,27 @@ func TestRestController(t *testing.T) {
 	a.Controller(&_c).routeRestControllers()
 	require.Equal(t, a.controllers[0], &_c)
 }
+
+type _middlewareController struct {
+	_controller
+	trace *[]string
+}
+
+func (c *_middlewareController) Middlewares() []middleware.Middleware {
+	return []middleware.Middleware{_traceMiddleware{c.trace, "controller"}}
+}
+
+func TestRestMiddlewareController(t *testing.T) {
+	var trace []string
+	a := New().Use(_traceMiddleware{&trace, "global"}).Controller(&_middlewareController{trace: &trace})
+	require.Nil(t, a.prepare())
+	d := a.newDispatcher()
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/_", nil),
+		httptest.NewRequest(http.MethodPut, "/_/1", nil),
+	} {
+		trace = nil
+		d.ServeHTTP(httptest.NewRecorder(), req)
+		require.Equal(t, []string{"global", "controller"}, trace, req.URL.Path)
+	}
+}
@@ -15,68 +15,123 @@ import (
 	"embed"
 	"fmt"
 	"github.com/go-the-way/anoweb/context"
+	"github.com/go-the-way/anoweb/middleware"
 	"github.com/go-the-way/anoweb/router"
+	"github.com/go-the-way/anoweb/websocket"
 	"net/http"
+	"regexp"
+	"sort"
+	"strings"
 )
 
 // Request Route all Methods
-func (a *App) Request(pattern string, handler func(ctx *context.Context)) *App {
-	return a.Route("*", pattern, handler)
+func (a *App) Request(pattern string, handler func(ctx *context.Context), middlewares ...middleware.Middleware) *App {
+	return a.Route("*", pattern, handler, middlewares...)
 }
 
 // Get Route Get Method
-func (a *App) Get(pattern string, handler func(ctx *context.Context)) *App {
-	return a.Route(http.MethodGet, pattern, handler)
+func (a *App) Get(pattern string, handler func(ctx *context.Context), middlewares ...middleware.Middleware) *App {
+	return a.Route(http.MethodGet, pattern, handler, middlewares...)
 }
 
 // Post Route Post Method
-func (a *App) Post(pattern string, handler func(ctx *context.Context)) *App {
-	return a.Route(http.MethodPost, pattern, handler)
+func (a *App) Post(pattern string, handler func(ctx *context.Context), middlewares ...middleware.Middleware) *App {
+	return a.Route(http.MethodPost, pattern, handler, middlewares...)
 }
 
 // Put Route Put Method
-func (a *App) Put(pattern string, handler func(ctx *context.Context)) *App {
-	return a.Route(http.MethodPut, pattern, handler)
+func (a *App) Put(pattern string, handler func(ctx *context.Context), middlewares ...middleware.Middleware) *App {
+	return a.Route(http.MethodPut, pattern, handler, middlewares...)
 }
 
 // Delete Route Delete Method
-func (a *App) Delete(pattern string, handler func(ctx *context.Context)) *App {
-	return a.Route(http.MethodDelete, pattern, handler)
+func (a *App) Delete(pattern string, handler func(ctx *context.Context), middlewares ...middleware.Middleware) *App {
+	return a.Route(http.MethodDelete, pattern, handler, middlewares...)
 }
 
 // Patch Route Patch Method
-func (a *App) Patch(pattern string, handler func(ctx *context.Context)) *App {
-	return a.Route(http.MethodPatch, pattern, handler)
+func (a *App) Patch(pattern string, handler func(ctx *context.Context), middlewares ...middleware.Middleware) *App {
+	return a.Route(http.MethodPatch, pattern, handler, middlewares...)
 }
 
 // Head Route Head Method
-func (a *App) Head(pattern string, handler func(ctx *context.Context)) *App {
-	return a.Route(http.MethodHead, pattern, handler)
+func (a *App) Head(pattern string, handler func(ctx *context.Context), middlewares ...middleware.Middleware) *App {
+	return a.Route(http.MethodHead, pattern, handler, middlewares...)
 }
 
 // Options Route Options Method
-func (a *App) Options(pattern string, handler func(ctx *context.Context)) *App {
-	return a.Route(http.MethodOptions, pattern, handler)
+func (a *App) Options(pattern string, handler func(ctx *context.Context), middlewares ...middleware.Middleware) *App {
+	return a.Route(http.MethodOptions, pattern, handler, middlewares...)
 }
 
-// Route Route DIY Method
-func (a *App) Route(method, pattern string, handler func(ctx *context.Context)) *App {
-	a.routers[0].Route(method, pattern, handler)
+// Route Route DIY Method, the middlewares run after the global ones
+func (a *App) Route(method, pattern string, handler func(ctx *context.Context), middlewares ...middleware.Middleware) *App {
+	a.routers[0].Route(method, pattern, handler, middlewares...)
 	return a
 }
 
+// Override Route DIY Method, replaces the same route, or the overlapping dynamic one (see router.Overlap),
+// registered by other routers, groups or controllers
+// instead of failing the startup with a conflict
+func (a *App) Override(method, pattern string, handler func(ctx *context.Context), middlewares ...middleware.Middleware) *App {
+	a.routers[0].Override(method, pattern, handler, middlewares...)
+	return a
+}
+
+// Name names the route registered last by the App, for building its URL with URL
+func (a *App) Name(name string) *App {
+	a.routers[0].Name(name)
+	return a
+}
+
+// URL return the path of the named route with the params, the params are pairs of name and value,
+// the values of the path params are escaped, the other params are added as the query string.
+// The routes are named once the App is prepared by Handler, Run or Start
+func (a *App) URL(name string, params ...interface{}) (string, error) {
+	reg, have := a.routeNames[name]
+	if !have {
+		return "", fmt.Errorf("%w: %s", router.ErrRouteNotFound, name)
+	}
+	return reg.URL(params...)
+}
+
 // Resource Route a resource
-func (a *App) Resource(pattern, file, contentType string) *App {
+func (a *App) Resource(pattern, file, contentType string, middlewares ...middleware.Middleware) *App {
 	return a.Route(http.MethodGet, pattern, func(ctx *context.Context) {
 		ctx.File(file, contentType)
-	})
+	}, middlewares...)
 }
 
 // FSResource Route a resource
-func (a *App) FSResource(fs *embed.FS, pattern, file, contentType string) *App {
+func (a *App) FSResource(fs *embed.FS, pattern, file, contentType string, middlewares ...middleware.Middleware) *App {
 	return a.Route(http.MethodGet, pattern, func(ctx *context.Context) {
 		ctx.FSFile(fs, file, contentType)
-	})
+	}, middlewares...)
+}
+
+// Mount Route the std http.Handler for all methods under the prefix, the prefix is not stripped
+func (a *App) Mount(prefix string, handler http.Handler) *App {
+	a.routers[0].Mount(prefix, handler)
+	return a
+}
+
+// WebSocket Route the WebSocket endpoint, see router.Router.WebSocket
+func (a *App) WebSocket(pattern string, handler func(ctx *context.Context, conn *websocket.Conn), upgrader ...*websocket.Upgrader) *App {
+	a.routers[0].WebSocket(pattern, handler, upgrader...)
+	return a
+}
+
+// NotFound Sets the handler for the unmatched paths, the status is 404 unless the handler sets another one
+func (a *App) NotFound(handler func(ctx *context.Context)) *App {
+	a.fallback.NotFound = handler
+	return a
+}
+
+// MethodNotAllowed Sets the handler for the paths routed for other methods only,
+// the Allow header is set, the status is 405 unless the handler sets another one
+func (a *App) MethodNotAllowed(handler func(ctx *context.Context)) *App {
+	a.fallback.MethodNotAllowed = handler
+	return a
 }
 
 // AddRouter Add Routers
@@ -91,16 +146,37 @@ func (a *App) AddRouterGroup(g ...*router.Group) *App {
 	return a
 }
 
-func (a *App) simpleParseFunc(prefix string, simples []*router.Simple) {
-	for _, simple := range simples {
-		routeKey := fmt.Sprintf("%s:%s%s", simple.Method, prefix, simple.Pattern)
-		a.parsedRouters.Simples[routeKey] = simple
+// routeScope the prefix, the middlewares and the metadata of the routes in a router
+type routeScope struct {
+	prefix      string
+	middlewares []middleware.Middleware
+	metadata    map[string]interface{}
+}
+
+func (a *App) simpleParseFunc(s routeScope, r *router.Router) {
+	for _, simple := range r.Simples {
+		routeKey := fmt.Sprintf("%s:%s%s", simple.Method, s.prefix, simple.Pattern)
+		reg := r.Registered(simple)
+		routeMws := routeMiddlewares(s.middlewares, reg)
+		if a.register(routeKey, routeKey, s.prefix, simple, nil, reg, routeMws) {
+			a.routes[routeKey].Metadata = s.metadata
+			a.parsedRouters.Simples[routeKey] = withMiddlewares(simple, routeMws)
+		}
 	}
 }
 
-func (a *App) dynamicParseFunc(prefix string, dynamics []*router.Dynamic) {
-	for _, d := range dynamics {
-		pattern := fmt.Sprintf("^%s%s$", prefix, d.Pattern)
+func (a *App) dynamicParseFunc(s routeScope, r *router.Router) {
+	for _, d := range r.Dynamics {
+		pattern := fmt.Sprintf("^%s%s$", regexp.QuoteMeta(s.prefix), d.Pattern)
+		reg := r.Registered(d.Simple)
+		routeMws := routeMiddlewares(s.middlewares, reg)
+		if !a.register(d.Method+":"+pattern, a.dynamicConflictKey(d.Method, pattern), s.prefix, d.Simple, d.Params, reg, routeMws) {
+			continue
+		}
+		a.routes[d.Method+":"+pattern].Metadata = s.metadata
+		if len(routeMws) > 0 {
+			d = &router.Dynamic{Params: d.Params, Simple: withMiddlewares(d.Simple, routeMws)}
+		}
 		if mm, have := a.parsedRouters.Dynamics[d.Method]; have {
 			mm[pattern] = d
 		} else {
@@ -109,20 +185,123 @@ func (a *App) dynamicParseFunc(prefix string, dynamics []*router.Dynamic) {
 	}
 }
 
-func (a *App) parseRouters() *App {
-	for _, g := range a.groups {
-		for _, gr := range g.Routers() {
-			if g.Prefix() == "" {
-				a.routers = append(a.routers, gr)
-			} else {
-				a.simpleParseFunc(g.Prefix(), gr.Simples)
-				a.dynamicParseFunc(g.Prefix(), gr.Dynamics)
+// dynamicConflictKey return the least conflict key of the registered dynamic routes overlapping the pattern,
+// see router.Overlap, the route key if none
+func (a *App) dynamicConflictKey(method, pattern string) string {
+	var keys []string
+	for conflictKey, key := range a.routeKeys {
+		if existing := strings.TrimPrefix(key, method+":^"); existing != key && router.Overlap(pattern, "^"+existing) {
+			keys = append(keys, conflictKey)
+		}
+	}
+	if len(keys) <= 0 {
+		return method + ":" + pattern
+	}
+	sort.Strings(keys)
+	return keys[0]
+}
+
+// routeMiddlewares return the middlewares of the group and the router followed by the route ones
+func routeMiddlewares(mws []middleware.Middleware, reg *router.Registration) []middleware.Middleware {
+	if reg == nil || len(reg.Middlewares) <= 0 {
+		return mws
+	}
+	return append(mws[:len(mws):len(mws)], reg.Middlewares...)
+}
+
+// withMiddlewares return the copy of the route running the middlewares, the route itself if none
+func withMiddlewares(simple *router.Simple, mws []middleware.Middleware) *router.Simple {
+	if len(mws) <= 0 {
+		return simple
+	}
+	return &router.Simple{Method: simple.Method, Pattern: simple.Pattern, Handler: router.WithMiddlewares(simple.Handler, mws...)}
+}
+
+// register reports whether the route should be parsed, the first conflict with the route of the same
+// conflict key registered before fails the startup, unless one of them overrides.
+// The conflict key of the dynamic routes is the one of the overlapping route, see App.dynamicConflictKey
+func (a *App) register(key, conflictKey, prefix string, simple *router.Simple, params []string, reg *router.Registration, mws []middleware.Middleware) bool {
+	route := &router.Registration{Pattern: prefix + simple.Pattern}
+	if reg != nil {
+		route = &router.Registration{Pattern: prefix + reg.Pattern, Site: reg.Site, Override: reg.Override, Name: reg.Name}
+	}
+	existing, have := a.registrations[conflictKey]
+	switch {
+	case !have, route.Override && !existing.Override:
+	case existing.Override && !route.Override:
+		return false
+	default:
+		if a.routeErr == nil {
+			a.routeErr = &router.ConflictError{Method: simple.Method, Route: route, Existing: existing}
+		}
+		return false
+	}
+	if overridden := a.routeKeys[conflictKey]; have && overridden != key {
+		delete(a.routes, overridden)
+		delete(a.parsedRouters.Dynamics[simple.Method], strings.TrimPrefix(overridden, simple.Method+":"))
+	}
+	a.registrations[conflictKey] = route
+	a.routeKeys[conflictKey] = key
+	info := &RouteInfo{simple.Method, route.Pattern, params, route.Name, funcName(simple.Handler), prefix, nil, route.Site, nil}
+	if info.Pattern == "" {
+		info.Pattern = "/"
+	}
+	for _, m := range mws {
+		info.Middlewares = append(info.Middlewares, middlewareName(m))
+	}
+	a.routes[key] = info
+	if route.Name != "" {
+		if named, have := a.routeNames[route.Name]; have && named.Pattern != route.Pattern {
+			if a.routeErr == nil {
+				a.routeErr = fmt.Errorf("router: route name %s of %s registered at %s is used by %s registered at %s",
+					route.Name, route.Pattern, route.Site, named.Pattern, named.Site)
 			}
+		} else if !have {
+			a.routeNames[route.Name] = route
 		}
 	}
+	return true
+}
+
+func (a *App) mountParseFunc(prefix string, mounts []*router.Mount, mws []middleware.Middleware) {
+	for _, m := range mounts {
+		a.parsedRouters.AddMount(&router.Mount{Prefix: prefix + m.Prefix, Handler: router.WithMiddlewares(m.Handler, mws...)})
+	}
+}
+
+// parseRouter parses the routes and the mounts of the router, the middlewares of the scope run first
+func (a *App) parseRouter(s routeScope, r *router.Router) {
+	mws := s.middlewares
+	s.middlewares = append(mws[:len(mws):len(mws)], r.Middlewares()...)
+	a.simpleParseFunc(s, r)
+	a.dynamicParseFunc(s, r)
+	a.mountParseFunc(s.prefix, r.Mounts, s.middlewares)
+}
+
+// parseGroup parses the routers of the group and the nested groups
+func (a *App) parseGroup(g *router.Group) {
+	if f := g.Fallback(); f != nil {
+		a.parsedRouters.AddFallback(f)
+	}
+	s := routeScope{g.Prefix(), g.Middlewares(), g.Metadata()}
+	for _, r := range g.Routers() {
+		a.parseRouter(s, r)
+	}
+	for _, child := range g.Groups() {
+		a.parseGroup(child)
+	}
+}
+
+func (a *App) parseRouters() *App {
+	a.parsedRouters.AddFallback(a.fallback)
+	for _, g := range a.groups {
+		a.parseGroup(g)
+	}
 	for _, r := range a.routers {
-		a.simpleParseFunc("", r.Simples)
-		a.dynamicParseFunc("", r.Dynamics)
+		a.parseRouter(routeScope{}, r)
+	}
+	if err := a.parsedRouters.Build(); err != nil && a.routeErr == nil {
+		a.routeErr = err
 	}
 	return a
 }
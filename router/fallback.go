@@ -0,0 +1,86 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package router
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-the-way/anoweb/context"
+	"github.com/go-the-way/anoweb/headers"
+)
+
+// Fallback defines the handlers for the unmatched paths under the prefix
+type Fallback struct {
+	// Prefix fallback prefix
+	Prefix string
+	// NotFound not found handler
+	NotFound func(ctx *context.Context)
+	// MethodNotAllowed method not allowed handler
+	MethodNotAllowed func(ctx *context.Context)
+}
+
+// Match reports whether the path is the prefix or under the prefix
+func (f *Fallback) Match(path string) bool {
+	return f.Prefix == "" || path == f.Prefix || strings.HasPrefix(path, f.Prefix+"/")
+}
+
+// NotFound return the not found handler, the status is 404 unless the handler sets another one
+func NotFound(handler func(ctx *context.Context)) func(ctx *context.Context) {
+	return fallbackHandler(http.StatusNotFound, handler)
+}
+
+// MethodNotAllowed return the method not allowed handler with the Allow header,
+// the status is 405 unless the handler sets another one
+func MethodNotAllowed(allowed []string, handler func(ctx *context.Context)) func(ctx *context.Context) {
+	h := fallbackHandler(http.StatusMethodNotAllowed, handler)
+	return func(ctx *context.Context) {
+		if written(ctx) {
+			return
+		}
+		ctx.Response.Header.Set(headers.Allow, strings.Join(allowed, ", "))
+		h(ctx)
+	}
+}
+
+// Options return the automatic OPTIONS handler with the Allow header
+func Options(allowed []string) func(ctx *context.Context) {
+	return func(ctx *context.Context) {
+		if written(ctx) {
+			return
+		}
+		ctx.Response.Header.Set(headers.Allow, strings.Join(allowed, ", "))
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+func fallbackHandler(status int, handler func(ctx *context.Context)) func(ctx *context.Context) {
+	return func(ctx *context.Context) {
+		if written(ctx) {
+			return
+		}
+		if handler == nil {
+			ctx.Text(http.StatusText(status))
+			ctx.Status(status)
+			return
+		}
+		handler(ctx)
+		if !ctx.Committed() && ctx.Response.Status == http.StatusOK {
+			ctx.Status(status)
+		}
+	}
+}
+
+// written reports whether a middleware wrote the response already, then the fallbacks are skipped
+func written(ctx *context.Context) bool {
+	return ctx.Committed() || ctx.Response.Data != nil
+}
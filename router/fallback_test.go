@@ -0,0 +1,82 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-the-way/anoweb/context"
+	"github.com/go-the-way/anoweb/headers"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testFallbackContext(method, path string) *context.Context {
+	ctx := context.New()
+	ctx.Allocate(httptest.NewRequest(method, path, nil), nil)
+	return ctx
+}
+
+func TestFallbackMatch(t *testing.T) {
+	require.True(t, (&Fallback{}).Match("/any"))
+	f := &Fallback{Prefix: "/api"}
+	require.True(t, f.Match("/api"))
+	require.True(t, f.Match("/api/users"))
+	require.False(t, f.Match("/apis"))
+}
+
+func TestNotFound(t *testing.T) {
+	{
+		ctx := testFallbackContext(http.MethodGet, "/")
+		NotFound(nil)(ctx)
+		require.Equal(t, http.StatusNotFound, ctx.Response.Status)
+		require.Equal(t, "Not Found", string(ctx.Response.Data))
+	}
+	{
+		ctx := testFallbackContext(http.MethodGet, "/")
+		NotFound(func(ctx *context.Context) { ctx.JSON(map[string]string{"error": "missing"}) })(ctx)
+		require.Equal(t, http.StatusNotFound, ctx.Response.Status)
+		require.Equal(t, `{"error":"missing"}`, string(ctx.Response.Data))
+	}
+	{
+		ctx := testFallbackContext(http.MethodGet, "/")
+		NotFound(func(ctx *context.Context) { ctx.Status(http.StatusGone) })(ctx)
+		require.Equal(t, http.StatusGone, ctx.Response.Status)
+	}
+	{
+		ctx := testFallbackContext(http.MethodGet, "/")
+		ctx.Text("written by middleware")
+		NotFound(nil)(ctx)
+		require.Equal(t, http.StatusOK, ctx.Response.Status)
+		require.Equal(t, "written by middleware", string(ctx.Response.Data))
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	ctx := testFallbackContext(http.MethodPost, "/")
+	MethodNotAllowed([]string{http.MethodGet, http.MethodHead, http.MethodOptions}, nil)(ctx)
+	require.Equal(t, http.StatusMethodNotAllowed, ctx.Response.Status)
+	require.Equal(t, "GET, HEAD, OPTIONS", ctx.Response.Header.Get(headers.Allow))
+	ctx = testFallbackContext(http.MethodPost, "/")
+	ctx.Text("written by middleware")
+	MethodNotAllowed([]string{http.MethodGet}, nil)(ctx)
+	require.Equal(t, "", ctx.Response.Header.Get(headers.Allow))
+}
+
+func TestOptions(t *testing.T) {
+	ctx := testFallbackContext(http.MethodOptions, "/")
+	Options([]string{http.MethodPost, http.MethodOptions})(ctx)
+	require.Equal(t, http.StatusNoContent, ctx.Response.Status)
+	require.Equal(t, "POST, OPTIONS", ctx.Response.Header.Get(headers.Allow))
+}
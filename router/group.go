@@ -11,10 +11,22 @@
 
 package router
 
+import (
+	"github.com/go-the-way/anoweb/context"
+	"github.com/go-the-way/anoweb/middleware"
+	"github.com/go-the-way/anoweb/util"
+)
+
 // Group struct
 type Group struct {
-	prefix  string
-	routers []*Router
+	prefix           string
+	routers          []*Router
+	notFound         func(ctx *context.Context)
+	methodNotAllowed func(ctx *context.Context)
+	middlewares      []middleware.Middleware
+	metadata         map[string]interface{}
+	parent           *Group
+	groups           []*Group
 }
 
 // NewGroup return new group
@@ -31,12 +43,98 @@ func (g *Group) Add(r ...*Router) *Group {
 	return g
 }
 
-// Prefix return group's prefix
+// Group return the new group nested in the group, its prefix is joined after the group's,
+// the middlewares, the fallback handlers and the metadata are inherited.
+// The nested groups are parsed with the group, so they must not be added to the App
+func (g *Group) Group(prefix string) *Group {
+	child := NewGroup(prefix)
+	child.parent = g
+	g.groups = append(g.groups, child)
+	return child
+}
+
+// Groups return group's nested groups
+func (g *Group) Groups() []*Group {
+	return g.groups
+}
+
+// Prefix return group's full prefix joined after the parents' prefixes, empty for the root
 func (g *Group) Prefix() string {
-	return g.prefix
+	if g.parent == nil {
+		return util.TrimSpecialChars(g.prefix)
+	}
+	return util.TrimSpecialChars(g.parent.Prefix() + "/" + g.prefix)
 }
 
 // Routers return group's routers
 func (g *Group) Routers() []*Router {
 	return g.routers
 }
+
+// Use adds the middlewares running before all routes and mounts of the group, after the global ones
+func (g *Group) Use(middlewares ...middleware.Middleware) *Group {
+	g.middlewares = append(g.middlewares, middlewares...)
+	return g
+}
+
+// Middlewares return group's middlewares after the parents' ones
+func (g *Group) Middlewares() []middleware.Middleware {
+	if g.parent == nil {
+		return g.middlewares
+	}
+	mws := g.parent.Middlewares()
+	return append(mws[:len(mws):len(mws)], g.middlewares...)
+}
+
+// Meta sets the metadata of the routes in the group and the nested groups
+func (g *Group) Meta(key string, value interface{}) *Group {
+	if g.metadata == nil {
+		g.metadata = make(map[string]interface{})
+	}
+	g.metadata[key] = value
+	return g
+}
+
+// Metadata return the copy of group's metadata merged over the parents' ones, nil if none
+func (g *Group) Metadata() map[string]interface{} {
+	var metadata map[string]interface{}
+	if g.parent != nil {
+		metadata = g.parent.Metadata()
+	}
+	if metadata == nil && len(g.metadata) > 0 {
+		metadata = make(map[string]interface{}, len(g.metadata))
+	}
+	for k, v := range g.metadata {
+		metadata[k] = v
+	}
+	return metadata
+}
+
+// NotFound Sets the not found handler for the paths under the prefix
+func (g *Group) NotFound(handler func(ctx *context.Context)) *Group {
+	g.notFound = handler
+	return g
+}
+
+// MethodNotAllowed Sets the method not allowed handler for the paths under the prefix
+func (g *Group) MethodNotAllowed(handler func(ctx *context.Context)) *Group {
+	g.methodNotAllowed = handler
+	return g
+}
+
+// Fallback return group's fallback, the handlers not set are inherited from the parents, nil if none set
+func (g *Group) Fallback() *Fallback {
+	notFound, methodNotAllowed := g.notFound, g.methodNotAllowed
+	for p := g.parent; p != nil; p = p.parent {
+		if notFound == nil {
+			notFound = p.notFound
+		}
+		if methodNotAllowed == nil {
+			methodNotAllowed = p.methodNotAllowed
+		}
+	}
+	if notFound == nil && methodNotAllowed == nil {
+		return nil
+	}
+	return &Fallback{g.Prefix(), notFound, methodNotAllowed}
+}
@@ -14,6 +14,8 @@ package router
 import (
 	"testing"
 
+	"github.com/go-the-way/anoweb/context"
+
 	"github.com/stretchr/testify/require"
 )
 
@@ -48,3 +50,62 @@ func TestGroupRouters(t *testing.T) {
 	g.Add(&Router{})
 	require.Equal(t, 3, len(g.Routers()))
 }
+
+func TestGroupFallback(t *testing.T) {
+	g := NewGroup("/api/")
+	require.Nil(t, g.Fallback())
+	g.NotFound(func(ctx *context.Context) {})
+	f := g.Fallback()
+	require.Equal(t, "/api", f.Prefix)
+	require.NotNil(t, f.NotFound)
+	require.Nil(t, f.MethodNotAllowed)
+	require.NotNil(t, g.MethodNotAllowed(func(ctx *context.Context) {}).Fallback().MethodNotAllowed)
+}
+
+func TestGroupUse(t *testing.T) {
+	var trace []string
+	g := NewGroup("/admin").Use(traceMiddleware(&trace, "a")).Use(traceMiddleware(&trace, "b"), traceMiddleware(&trace, "c"))
+	require.Len(t, g.Middlewares(), 3)
+	require.Nil(t, NewGroup("").Middlewares())
+}
+
+func TestGroupGroup(t *testing.T) {
+	root := NewGroup("/")
+	api := root.Group("api/")
+	v1 := api.Group("/v1")
+	empty := v1.Group("")
+	slash := v1.Group("/")
+	require.Equal(t, "", root.Prefix())
+	require.Equal(t, "/api", api.Prefix())
+	require.Equal(t, "/api/v1", v1.Prefix())
+	require.Equal(t, "/api/v1", empty.Prefix())
+	require.Equal(t, "/api/v1", slash.Prefix())
+	require.Equal(t, "/api/v1/users", slash.Group("//users/").Prefix())
+	require.Equal(t, []*Group{api}, root.Groups())
+	require.Equal(t, []*Group{empty, slash}, v1.Groups())
+}
+
+func TestGroupInherit(t *testing.T) {
+	var trace []string
+	api := NewGroup("/api").Use(traceMiddleware(&trace, "api")).Meta("auth", true).Meta("version", 0)
+	v1 := api.Group("/v1").Use(traceMiddleware(&trace, "v1")).Meta("version", 1)
+	v2 := api.Group("/v2")
+	require.Len(t, v1.Middlewares(), 2)
+	require.Len(t, v2.Middlewares(), 1)
+	require.Len(t, api.Middlewares(), 1)
+	require.Equal(t, map[string]interface{}{"auth": true, "version": 1}, v1.Metadata())
+	require.Equal(t, map[string]interface{}{"auth": true, "version": 0}, v2.Metadata())
+	require.Equal(t, map[string]interface{}{"auth": true, "version": 0}, api.Metadata())
+	require.Nil(t, NewGroup("").Group("/a").Metadata())
+	v2.Metadata()["auth"] = false
+	require.Equal(t, true, api.Metadata()["auth"])
+
+	require.Nil(t, v1.Fallback())
+	api.NotFound(func(ctx *context.Context) {})
+	v1.MethodNotAllowed(func(ctx *context.Context) {})
+	f := v1.Group("/users").Fallback()
+	require.Equal(t, "/api/v1/users", f.Prefix)
+	require.NotNil(t, f.NotFound)
+	require.NotNil(t, f.MethodNotAllowed)
+	require.Nil(t, v2.Fallback().MethodNotAllowed)
+}
@@ -0,0 +1,34 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package router
+
+import (
+	"github.com/go-the-way/anoweb/context"
+	"github.com/go-the-way/anoweb/middleware"
+)
+
+// WithMiddlewares return the handler running the middlewares before the handler,
+// the handlers are added to the running chain, so the middlewares can call ctx.Next or ctx.Abort
+func WithMiddlewares(handler func(ctx *context.Context), middlewares ...middleware.Middleware) func(ctx *context.Context) {
+	if len(middlewares) <= 0 {
+		return handler
+	}
+	handlers := make([]func(ctx *context.Context), 0, len(middlewares)+1)
+	for _, m := range middlewares {
+		handlers = append(handlers, m.Handler())
+	}
+	handlers = append(handlers, handler)
+	return func(ctx *context.Context) {
+		ctx.Add(handlers...)
+		ctx.Chain()
+	}
+}
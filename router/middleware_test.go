@@ -0,0 +1,65 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/go-the-way/anoweb/config"
+	"github.com/go-the-way/anoweb/context"
+
+	"github.com/stretchr/testify/require"
+)
+
+type handlerMiddleware func(ctx *context.Context)
+
+func (m handlerMiddleware) Handler() func(ctx *context.Context) {
+	return m
+}
+
+func traceMiddleware(trace *[]string, name string) handlerMiddleware {
+	return func(ctx *context.Context) {
+		*trace = append(*trace, name)
+		ctx.Next()
+	}
+}
+
+func TestWithMiddlewares(t *testing.T) {
+	handler := text("ok")
+	require.Equal(t, reflect.ValueOf(handler).Pointer(), reflect.ValueOf(WithMiddlewares(handler)).Pointer())
+	var trace []string
+	wrapped := WithMiddlewares(func(ctx *context.Context) {
+		trace = append(trace, "handler")
+		ctx.Text("ok")
+	}, traceMiddleware(&trace, "a"), traceMiddleware(&trace, "b"))
+	ctx := context.New()
+	ctx.Allocate(httptest.NewRequest(http.MethodGet, "/", nil), &config.Template{})
+	wrapped(ctx)
+	require.Equal(t, []string{"a", "b", "handler"}, trace)
+	require.Equal(t, "ok", string(ctx.Response.Data))
+
+	trace = nil
+	aborted := WithMiddlewares(func(ctx *context.Context) {
+		trace = append(trace, "handler")
+	}, handlerMiddleware(func(ctx *context.Context) {
+		ctx.AbortWithStatus(http.StatusUnauthorized)
+		ctx.Next()
+	}))
+	ctx = context.New()
+	ctx.Allocate(httptest.NewRequest(http.MethodGet, "/", nil), &config.Template{})
+	aborted(ctx)
+	require.Nil(t, trace)
+	require.Equal(t, http.StatusUnauthorized, ctx.Response.Status)
+}
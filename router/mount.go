@@ -0,0 +1,31 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package router
+
+import (
+	"strings"
+
+	"github.com/go-the-way/anoweb/context"
+)
+
+// Mount defines a handler mounted under a path prefix
+type Mount struct {
+	// Prefix mount prefix
+	Prefix string
+	// Handler mount handler
+	Handler func(ctx *context.Context)
+}
+
+// Match reports whether the path is the prefix or under the prefix
+func (m *Mount) Match(path string) bool {
+	return m.Prefix == "" || path == m.Prefix || strings.HasPrefix(path, m.Prefix+"/")
+}
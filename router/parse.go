@@ -13,7 +13,10 @@ package router
 
 import (
 	"fmt"
-	"regexp"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/go-the-way/anoweb/context"
 	"github.com/go-the-way/anoweb/util"
@@ -30,50 +33,164 @@ type (
 		Simples SimpleM
 		// Dynamics routers K<Method> V< K<Pattern> V<Simple> >
 		Dynamics DynamicM
+		// Mounts mounted handlers, the longest prefix first
+		Mounts []*Mount
+		// Fallbacks not found and method not allowed handlers, the longest prefix first
+		Fallbacks []*Fallback
+		// trees the radix trees per method, built from the Simples and the Dynamics by Build
+		trees    map[string]*node
+		once     sync.Once
+		buildErr error
 	}
 )
 
-// Handler found simple or dynamic handler
+// Handler found simple or dynamic handler, HEAD falls back to GET,
+// otherwise return the OPTIONS, method not allowed or not found handler
 func (pr *ParsedRouter) Handler(ctx *context.Context) func(ctx *context.Context) {
-	simple := pr.simple(ctx)
-	if simple != nil {
-		return simple
+	reqPath := util.ReBuildPath(ctx.Request.URL.Path)
+	method := ctx.Request.Method
+	if handler := pr.route(ctx, method, reqPath); handler != nil {
+		return handler
 	}
-	return pr.dynamic(ctx)
+	if method == http.MethodHead {
+		if handler := pr.route(ctx, http.MethodGet, reqPath); handler != nil {
+			return handler
+		}
+	}
+	if mount := pr.mount(reqPath); mount != nil {
+		return mount
+	}
+	allowed := pr.Allowed(reqPath)
+	if len(allowed) <= 0 {
+		return NotFound(pr.fallback(reqPath, func(f *Fallback) func(ctx *context.Context) { return f.NotFound }))
+	}
+	if method == http.MethodOptions {
+		return Options(allowed)
+	}
+	return MethodNotAllowed(allowed, pr.fallback(reqPath, func(f *Fallback) func(ctx *context.Context) { return f.MethodNotAllowed }))
 }
 
-func (pr *ParsedRouter) simple(ctx *context.Context) func(ctx *context.Context) {
-	if len(pr.Simples) <= 0 {
-		return nil
+// Allowed return the methods routed for the path, with HEAD for GET and OPTIONS, nil if none
+func (pr *ParsedRouter) Allowed(reqPath string) []string {
+	var allowed []string
+	for _, m := range supportedMethods {
+		if route, _ := pr.lookup(m, reqPath); route != nil ||
+			m == http.MethodHead && len(allowed) > 0 && allowed[0] == http.MethodGet {
+			allowed = append(allowed, m)
+		}
 	}
-	routeKey := fmt.Sprintf("%s:%s", ctx.Request.Method, util.ReBuildPath(ctx.Request.URL.Path))
-	if simple, have := pr.Simples[routeKey]; have {
-		return simple.Handler
+	if len(allowed) > 0 && allowed[len(allowed)-1] != http.MethodOptions {
+		allowed = append(allowed, http.MethodOptions)
+	}
+	return allowed
+}
+
+// AddFallback adds the fallback, keeps the longest prefix first
+func (pr *ParsedRouter) AddFallback(f *Fallback) {
+	pr.Fallbacks = append(pr.Fallbacks, f)
+	sort.SliceStable(pr.Fallbacks, func(i, j int) bool { return len(pr.Fallbacks[i].Prefix) > len(pr.Fallbacks[j].Prefix) })
+}
+
+func (pr *ParsedRouter) fallback(reqPath string, handler func(f *Fallback) func(ctx *context.Context)) func(ctx *context.Context) {
+	for _, f := range pr.Fallbacks {
+		if h := handler(f); h != nil && f.Match(reqPath) {
+			return h
+		}
 	}
 	return nil
 }
 
-func (pr *ParsedRouter) dynamic(ctx *context.Context) func(ctx *context.Context) {
-	if len(pr.Dynamics) <= 0 {
+func (pr *ParsedRouter) route(ctx *context.Context, method, reqPath string) func(ctx *context.Context) {
+	route, values := pr.lookup(method, reqPath)
+	if route == nil {
 		return nil
 	}
-	if mp, have := pr.Dynamics[ctx.Request.Method]; have {
-		for k, v := range mp {
-			re := regexp.MustCompile(k)
-			reqPath := util.ReBuildPath(ctx.Request.URL.Path)
-			finds := re.FindAllStringSubmatch(reqPath, -1)
-			if len(finds) > 0 {
-				subFinds := finds[0]
-				if len(subFinds) == len(v.Params)+1 {
-					paramsMap := make(map[string][]string, len(v.Params))
-					for i, f := range subFinds[1:] {
-						paramsMap[v.Params[i]] = []string{f}
-					}
-					ctx.SetParamMap(paramsMap, false)
-					return v.Handler
-				}
+	if len(values) > 0 {
+		paramsMap := make(map[string][]string, len(values))
+		for i, v := range values {
+			paramsMap[route.params[i]] = []string{v}
+		}
+		ctx.SetParamMap(paramsMap, false)
+	}
+	return route.handler
+}
+
+// AddMount adds the mount, keeps the longest prefix first
+func (pr *ParsedRouter) AddMount(m *Mount) {
+	pr.Mounts = append(pr.Mounts, m)
+	sort.SliceStable(pr.Mounts, func(i, j int) bool { return len(pr.Mounts[i].Prefix) > len(pr.Mounts[j].Prefix) })
+}
+
+func (pr *ParsedRouter) mount(reqPath string) func(ctx *context.Context) {
+	for _, m := range pr.Mounts {
+		if m.Match(reqPath) {
+			return m.Handler
+		}
+	}
+	return nil
+}
+
+func (pr *ParsedRouter) lookup(method, reqPath string) (*treeRoute, []string) {
+	_ = pr.Build()
+	if root, have := pr.trees[method]; have {
+		return root.lookup(reqPath, nil)
+	}
+	return nil, nil
+}
+
+// Build builds the trees of the routes once, return the error of the invalid dynamic patterns,
+// no route is matched then. The first lookup builds the trees unless built before.
+func (pr *ParsedRouter) Build() error {
+	pr.once.Do(func() { pr.buildErr = pr.build() })
+	return pr.buildErr
+}
+
+// build builds the trees of the Dynamics and the Simples, in the sorted order of the keys
+func (pr *ParsedRouter) build() (err error) {
+	defer func() {
+		if re := recover(); re != nil {
+			err = fmt.Errorf("router: %v", re)
+		}
+	}()
+	trees := make(map[string]*node)
+	tree := func(method string) *node {
+		root, have := trees[method]
+		if !have {
+			root = &node{}
+			trees[method] = root
+		}
+		return root
+	}
+	methods := make([]string, 0, len(pr.Dynamics))
+	for method := range pr.Dynamics {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	for _, method := range methods {
+		patterns := make([]string, 0, len(pr.Dynamics[method]))
+		for pattern := range pr.Dynamics[method] {
+			patterns = append(patterns, pattern)
+		}
+		sort.Strings(patterns)
+		for _, pattern := range patterns {
+			d := pr.Dynamics[method][pattern]
+			tokens := parsePattern(pattern)
+			if paramCount(tokens) != len(d.Params) {
+				return fmt.Errorf("router: the %d groups of pattern %s mismatch the params %v", paramCount(tokens), pattern, d.Params)
 			}
+			tree(method).insert(tokens, &treeRoute{d.Params, d.Handler})
+		}
+	}
+	keys := make([]string, 0, len(pr.Simples))
+	for key := range pr.Simples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if i := strings.IndexByte(key, ':'); i >= 0 {
+			tree(key[:i]).insert([]token{{static: key[i+1:]}}, &treeRoute{handler: pr.Simples[key].Handler})
 		}
 	}
+	pr.trees = trees
 	return nil
 }
@@ -13,6 +13,7 @@ package router
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/go-the-way/anoweb/config"
@@ -90,4 +91,62 @@ func TestParsedRouterHandler(t *testing.T) {
 		}
 		require.Equal(t, false, pass)
 	}
+	// test for mount
+	{
+		hit := ""
+		pr := &ParsedRouter{}
+		pr.AddMount(&Mount{"/a", func(ctx *context.Context) { hit = "a" }})
+		pr.AddMount(&Mount{"/a/b", func(ctx *context.Context) { hit = "ab" }})
+		for path, expect := range map[string]string{"/a": "a", "/a/c": "a", "/a/b/c": "ab", "/ab": "", "/b": ""} {
+			hit = ""
+			req, _ := http.NewRequest(http.MethodPost, path, nil)
+			ctx := context.New()
+			ctx.Allocate(req, &config.Template{})
+			if handler := pr.Handler(ctx); handler != nil {
+				handler(ctx)
+			}
+			require.Equal(t, expect, hit, path)
+		}
+	}
+}
+
+func TestParsedRouterFallback(t *testing.T) {
+	r := NewRouter().Get("/users", func(ctx *context.Context) { ctx.Text("users") }).
+		Post("/users/{id}", func(ctx *context.Context) {}).
+		Request("/any", func(ctx *context.Context) {})
+	pr := &ParsedRouter{Simples: SimpleM{}, Dynamics: DynamicM{}}
+	for _, s := range r.Simples {
+		pr.Simples[s.Method+":"+s.Pattern] = s
+	}
+	for _, d := range r.Dynamics {
+		pr.Dynamics[d.Method] = map[string]*Dynamic{"^" + d.Pattern + "$": d}
+	}
+	pr.AddFallback(&Fallback{NotFound: func(ctx *context.Context) { ctx.Text("app") }})
+	pr.AddFallback(&Fallback{Prefix: "/users", NotFound: func(ctx *context.Context) { ctx.Text("users") }})
+
+	require.Equal(t, []string{http.MethodGet, http.MethodHead, http.MethodOptions}, pr.Allowed("/users"))
+	require.Equal(t, []string{http.MethodPost, http.MethodOptions}, pr.Allowed("/users/1"))
+	require.Equal(t, supportedMethods, pr.Allowed("/any"))
+	require.Nil(t, pr.Allowed("/none"))
+
+	serve := func(method, path string) *context.Context {
+		ctx := context.New()
+		ctx.Allocate(httptest.NewRequest(method, path, nil), &config.Template{})
+		pr.Handler(ctx)(ctx)
+		return ctx
+	}
+	ctx := serve(http.MethodHead, "/users")
+	require.Equal(t, "users", string(ctx.Response.Data))
+	ctx = serve(http.MethodDelete, "/users")
+	require.Equal(t, http.StatusMethodNotAllowed, ctx.Response.Status)
+	require.Equal(t, "GET, HEAD, OPTIONS", ctx.Response.Header.Get("Allow"))
+	ctx = serve(http.MethodOptions, "/users/1")
+	require.Equal(t, http.StatusNoContent, ctx.Response.Status)
+	require.Equal(t, "POST, OPTIONS", ctx.Response.Header.Get("Allow"))
+	ctx = serve(http.MethodGet, "/users/1/books")
+	require.Equal(t, http.StatusNotFound, ctx.Response.Status)
+	require.Equal(t, "users", string(ctx.Response.Data))
+	ctx = serve(http.MethodGet, "/none")
+	require.Equal(t, http.StatusNotFound, ctx.Response.Status)
+	require.Equal(t, "app", string(ctx.Response.Data))
 }
@@ -0,0 +1,158 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package router
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-the-way/anoweb/util"
+)
+
+// ParamTypes the regexps of the typed params `{name:type}`
+var ParamTypes = map[string]string{
+	"int":   `[0-9]+`,
+	"alpha": `[a-zA-Z]+`,
+	"uuid":  `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+}
+
+// patternParam the param of a route pattern:
+//
+//	{name}        matches `[\w_.-]+` in a path segment
+//	{name:int}    matches the regexp of the type in ParamTypes
+//	{name:regexp} matches the regexp in a path segment
+//	{name*}       matches the rest of the path, must be the last
+//	{name?}       the optional last path segment, combined with the others like {name:int?},
+//	              the trailing `?` always means optional, wrap the regexp ending with `?` as `(?:a?)`
+type patternParam struct {
+	raw      string
+	name     string
+	regex    string
+	optional bool
+}
+
+// splitPattern trims the special chars outside the params, return the pattern
+// with the `{index}` placeholders of the params and the params, panics if the pattern is invalid
+func splitPattern(pattern string) (string, []*patternParam) {
+	var (
+		params []*patternParam
+		buf    strings.Builder
+	)
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '{':
+			end := braceEnd(pattern, i)
+			params = append(params, newPatternParam(pattern, pattern[i+1:end]))
+			buf.WriteString(placeholder(len(params) - 1))
+			i = end
+		case '}':
+			panic(fmt.Errorf("router: unopened brace in pattern %s", pattern))
+		default:
+			buf.WriteByte(pattern[i])
+		}
+	}
+	trimmed := util.TrimSpecialChars(buf.String())
+	for i, p := range params {
+		last := i == len(params)-1
+		switch {
+		case p.optional && (!last || !strings.HasSuffix(trimmed, "/"+placeholder(i))):
+			panic(fmt.Errorf("router: optional param %s must be the last path segment of pattern %s", p.name, pattern))
+		case p.regex == wildcardRep && (!last || !strings.HasSuffix(trimmed, placeholder(i))):
+			panic(fmt.Errorf("router: catch-all param %s must be the last of pattern %s", p.name, pattern))
+		}
+	}
+	return trimmed, params
+}
+
+func newPatternParam(pattern, raw string) *patternParam {
+	spec := strings.TrimSuffix(raw, "?")
+	p := &patternParam{raw: raw, name: spec, regex: dynamicParamRep[1 : len(dynamicParamRep)-1], optional: spec != raw}
+	if i := strings.IndexByte(spec, ':'); i >= 0 {
+		p.name, p.regex = spec[:i], spec[i+1:]
+		if rep, have := ParamTypes[p.regex]; have {
+			p.regex = rep
+		}
+	} else if strings.HasSuffix(spec, "*") {
+		p.name, p.regex = strings.TrimSuffix(spec, "*"), wildcardRep
+	}
+	p.name = strings.TrimSpace(p.name)
+	if p.name == "" || p.regex == "" {
+		panic(fmt.Errorf("router: invalid param {%s} in pattern %s", raw, pattern))
+	}
+	if _, err := regexp.Compile(p.regex); err != nil {
+		panic(fmt.Errorf("router: invalid param {%s} in pattern %s: %v", raw, pattern, err))
+	}
+	return p
+}
+
+// braceEnd return the index of the `}` closing the brace opened at the start
+func braceEnd(pattern string, start int) int {
+	depth := 0
+	for i := start; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	panic(fmt.Errorf("router: unclosed brace in pattern %s", pattern))
+}
+
+func placeholder(i int) string {
+	return "{" + strconv.Itoa(i) + "}"
+}
+
+// dynamicPattern return the regexp of the pattern with the placeholders, the static texts are quoted,
+// panics if the regexp is not split back into the params, see parsePattern
+func dynamicPattern(pattern string, params []*patternParam) string {
+	var buf strings.Builder
+	static := 0
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '{' {
+			continue
+		}
+		end := i + strings.IndexByte(pattern[i:], '}')
+		index, _ := strconv.Atoi(pattern[i+1 : end])
+		buf.WriteString(regexp.QuoteMeta(pattern[static:i]))
+		buf.WriteString("(" + params[index].regex + ")")
+		i, static = end, end+1
+	}
+	buf.WriteString(regexp.QuoteMeta(pattern[static:]))
+	dynamic := buf.String()
+	if count := paramCount(parsePattern(dynamic)); count != len(params) {
+		panic(fmt.Errorf("router: the %d groups of pattern %s mismatch its %d params", count, dynamic, len(params)))
+	}
+	return dynamic
+}
+
+// expandPattern replaces the placeholders with the results of the replace func
+func expandPattern(pattern string, replace func(i int) string) string {
+	var buf strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '{' {
+			buf.WriteByte(pattern[i])
+			continue
+		}
+		end := i + strings.IndexByte(pattern[i:], '}')
+		index, _ := strconv.Atoi(pattern[i+1 : end])
+		buf.WriteString(replace(index))
+		i = end
+	}
+	return buf.String()
+}
@@ -0,0 +1,112 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package router
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-the-way/anoweb/context"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitPattern(t *testing.T) {
+	pattern, params := splitPattern("//users/ {id:int} /files/{path*}")
+	require.Equal(t, "/users/{0}/files/{1}", pattern)
+	require.Equal(t, []*patternParam{
+		{"id:int", "id", ParamTypes["int"], false},
+		{"path*", "path", wildcardRep, false},
+	}, params)
+
+	pattern, params = splitPattern("/codes/{code:[0-9]{3}}.json/{page:int?}")
+	require.Equal(t, "/codes/{0}.json/{1}", pattern)
+	require.Equal(t, []*patternParam{
+		{"code:[0-9]{3}", "code", "[0-9]{3}", false},
+		{"page:int?", "page", ParamTypes["int"], true},
+	}, params)
+	require.Equal(t, "/codes/([0-9]{3}).json/{1}", expandPattern(pattern, func(i int) string {
+		if i == 0 {
+			return "(" + params[0].regex + ")"
+		}
+		return placeholder(i)
+	}))
+
+	for _, p := range []string{
+		"/users/{id", "/users/id}", "/users/{}", "/users/{:int}", "/users/{id:[0-9}",
+		"/users/{id?}/books", "/users/a{id?}", "/files/{path*}/meta", "/files/{path*}.json",
+	} {
+		require.Panics(t, func() { splitPattern(p) }, p)
+	}
+}
+
+func TestRouterPattern(t *testing.T) {
+	h := func(ctx *context.Context) {}
+	r := NewRouter().Get("/users/{id:int}", h).
+		Get("/slugs/{slug:[\\p{L}0-9-]+}", h).
+		Get("/files/{path*}", h).
+		Get("/posts/{page:int?}", h).
+		Get("/tags/{tag}/{page?}", h)
+	require.Len(t, r.Simples, 1)
+	require.Equal(t, "/posts", r.Simples[0].Pattern)
+	patterns := make([]string, 0)
+	for _, d := range r.Dynamics {
+		patterns = append(patterns, d.Pattern)
+	}
+	require.Equal(t, []string{
+		`/users/([0-9]+)`, `/slugs/([\p{L}0-9-]+)`, `/files/(.+)`, `/posts/([0-9]+)`, `/tags/([\w_.-]+)`, `/tags/([\w_.-]+)/([\w_.-]+)`,
+	}, patterns)
+	require.Equal(t, []string{"tag", "page"}, r.Dynamics[5].Params)
+	require.Equal(t, "/posts/{page:int?}", r.Registered(r.Simples[0]).Pattern)
+	require.Same(t, r.Registered(r.Simples[0]), r.Registered(r.Dynamics[3].Simple))
+}
+
+func TestDynamicPattern(t *testing.T) {
+	pattern, params := splitPattern("/v1.0/files/{name}.json/{id:(a|b)[0-9]}")
+	dynamic := dynamicPattern(pattern, params)
+	require.Equal(t, `/v1\.0/files/([\w_.-]+)\.json/((a|b)[0-9])`, dynamic)
+	tokens := parsePattern(dynamic)
+	require.Len(t, tokens, 4)
+	require.Equal(t, "/v1.0/files/", tokens[0].static)
+	require.Equal(t, ".json/", tokens[2].static)
+}
+
+func TestParsedRouterPattern(t *testing.T) {
+	pr := parsed(NewRouter().
+		Get("/users/{id:int}", text("id")).
+		Get("/users/{name}", text("name")).
+		Get("/slugs/{slug:[\\p{L}0-9-]+}", text("slug")).
+		Get("/files/{path*}", text("file")).
+		Get("/posts/{page:int?}", text("posts")).
+		Get("/uuids/{id:uuid}", text("uuid")))
+
+	for path, expect := range map[string][2]string{
+		"/users/12":            {"id", "12"},
+		"/users/bob":           {"name", "bob"},
+		"/slugs/héllo-wörld-2": {"slug", "héllo-wörld-2"},
+		"/files/a/b/c.txt":     {"file", "a/b/c.txt"},
+		"/posts":               {"posts", ""},
+		"/posts/3":             {"posts", "3"},
+		"/uuids/123e4567-e89b-12d3-a456-426614174000": {"uuid", "123e4567-e89b-12d3-a456-426614174000"},
+	} {
+		ctx := serveParsed(pr, http.MethodGet, path)
+		require.Equal(t, expect[0], string(ctx.Response.Data), path)
+		for _, name := range []string{"id", "name", "slug", "path", "page"} {
+			if v := ctx.Param(name); v != "" {
+				require.Equal(t, expect[1], v, path)
+			}
+		}
+	}
+	for _, path := range []string{"/files", "/posts/x", "/uuids/123", "/slugs/a_b"} {
+		require.Equal(t, http.StatusNotFound, serveParsed(pr, http.MethodGet, path).Response.Status, path)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package router
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/go-the-way/anoweb/middleware"
+)
+
+var registerFuncs = func() []string {
+	pkg := reflect.TypeOf(Router{}).PkgPath()
+	return []string{pkg + ".(*Router).", pkg + ".(*Group).", strings.TrimSuffix(pkg, "/router") + ".(*App)."}
+}()
+
+type (
+	// Registration defines where and how a route registered
+	Registration struct {
+		// Pattern the registered pattern
+		Pattern string
+		// Site the file:line registered the route
+		Site string
+		// Override the route replaces the same route registered elsewhere
+		Override bool
+		// Name the name for building the URL
+		Name string
+		// Middlewares the route middlewares
+		Middlewares []middleware.Middleware
+		once        sync.Once
+		url         *urlPattern
+	}
+	// ConflictError the route is registered twice, or matches the same paths as another route
+	ConflictError struct {
+		Method   string
+		Route    *Registration
+		Existing *Registration
+	}
+)
+
+// Error implements error
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("router: %s %s registered at %s conflicts with %s %s registered at %s",
+		e.Method, e.Route.Pattern, site(e.Route), e.Method, e.Existing.Pattern, site(e.Existing))
+}
+
+func site(r *Registration) string {
+	if r.Site == "" {
+		return "unknown"
+	}
+	return r.Site
+}
+
+// Caller return the file:line calling the routers, groups or App, empty if not found
+func Caller() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !registerFunc(frame.Function) {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+func registerFunc(function string) bool {
+	for _, prefix := range registerFuncs {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Shape return the parsed dynamic pattern `^/static/(param)$` with the params replaced by `{}`
+// and the wildcards by `{*}`, the patterns of the same shape differ in the param names or regexps only
+func Shape(pattern string) string {
+	var buf strings.Builder
+	for _, t := range parsePattern(pattern) {
+		switch {
+		case t.param == nil:
+			buf.WriteString(t.static)
+		case t.param.kind == wildcardKind:
+			buf.WriteString("{*}")
+		default:
+			buf.WriteString("{}")
+		}
+	}
+	return buf.String()
+}
+
+// Overlap reports whether the parsed dynamic patterns may match the same paths, so they conflict:
+// the patterns are of the same shape, and the params at every position have the same regexp,
+// or one of them is the default param `[\w_.-]+` or a wildcard
+func Overlap(pattern, other string) bool {
+	if Shape(pattern) != Shape(other) {
+		return false
+	}
+	tokens, others := parsePattern(pattern), parsePattern(other)
+	if len(tokens) != len(others) {
+		return false
+	}
+	for i, t := range tokens {
+		o := others[i].param
+		if t.param == nil || o == nil {
+			continue
+		}
+		if t.param.kind == regexKind && o.kind == regexKind && t.param.pattern != o.pattern {
+			return false
+		}
+	}
+	return true
+}
+
+// Registered return the registration of the route, nil if the route is not registered by the router
+func (r *Router) Registered(s *Simple) *Registration {
+	return r.registrations[s]
+}
+
+func (r *Router) register(s *Simple, reg *Registration) {
+	if r.registrations == nil {
+		r.registrations = make(map[*Simple]*Registration)
+	}
+	r.registrations[s] = reg
+}
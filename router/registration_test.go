@@ -0,0 +1,87 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"testing"
+
+	"github.com/go-the-way/anoweb/context"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouterRegistered(t *testing.T) {
+	_, file, line, _ := runtime.Caller(0)
+	r := NewRouter().Get("/users/{id}", func(ctx *context.Context) {}).
+		Override(http.MethodPost, "/users/", func(ctx *context.Context) {})
+	get := r.Registered(r.Dynamics[0].Simple)
+	require.Equal(t, &Registration{Pattern: "/users/{id}", Site: fmt.Sprintf("%s:%d", file, line+1)}, get)
+	post := r.Registered(r.Simples[0])
+	require.Equal(t, &Registration{Pattern: "/users", Site: fmt.Sprintf("%s:%d", file, line+2), Override: true}, post)
+	require.Nil(t, r.Registered(&Simple{}))
+	require.Nil(t, (&Router{}).Registered(&Simple{}))
+
+	r = NewRouter().Request("/any", func(ctx *context.Context) {})
+	require.Len(t, r.Simples, len(supportedMethods))
+	for _, s := range r.Simples {
+		require.Same(t, r.Registered(r.Simples[0]), r.Registered(s))
+	}
+}
+
+func TestCaller(t *testing.T) {
+	_, file, line, _ := runtime.Caller(0)
+	require.Equal(t, fmt.Sprintf("%s:%d", file, line+1), Caller())
+	require.True(t, registerFunc("github.com/go-the-way/anoweb/router.(*Router).Get"))
+	require.True(t, registerFunc("github.com/go-the-way/anoweb.(*App).Route"))
+	require.False(t, registerFunc("github.com/go-the-way/anoweb/router.TestCaller"))
+}
+
+func TestConflictError(t *testing.T) {
+	var err error = &ConflictError{http.MethodGet, &Registration{Pattern: "/users/{name}", Site: "b.go:2"}, &Registration{Pattern: "/users/{id}"}}
+	require.Equal(t, "router: GET /users/{name} registered at b.go:2 conflicts with GET /users/{id} registered at unknown", err.Error())
+	var ce *ConflictError
+	require.True(t, errors.As(fmt.Errorf("wrap: %w", err), &ce))
+	require.Equal(t, "/users/{id}", ce.Existing.Pattern)
+}
+
+func TestShape(t *testing.T) {
+	shape := func(pattern string) string {
+		p, params := splitPattern(pattern)
+		return Shape("^" + dynamicPattern(p, params) + "$")
+	}
+	require.Equal(t, "/x/{}", shape("/x/{a}"))
+	require.Equal(t, shape("/x/{a}"), shape("/x/{b:[a-z]+}"))
+	require.Equal(t, shape("/x/{a}/y"), shape("/x/{id:int}/y"))
+	require.Equal(t, "/x/{*}", shape("/x/{path*}"))
+	require.NotEqual(t, shape("/x/{a}.json"), shape("/x/{a}"))
+	require.NotEqual(t, shape("/x/{a}/y"), shape("/x/{a}/z"))
+}
+
+func TestOverlap(t *testing.T) {
+	overlap := func(pattern, other string) bool {
+		p, params := splitPattern(pattern)
+		o, others := splitPattern(other)
+		return Overlap("^"+dynamicPattern(p, params)+"$", "^"+dynamicPattern(o, others)+"$")
+	}
+	require.True(t, overlap("/x/{a}", "/x/{b:[a-z]+}"))
+	require.True(t, overlap("/x/{id:int}", "/x/{no:[0-9]+}"))
+	require.True(t, overlap("/x/{id:int}/{a}", "/x/{b}/{name:alpha}"))
+	require.True(t, overlap("/x/{path*}", "/x/{rest:.*}"))
+	require.False(t, overlap("/x/{id:int}", "/x/{name:alpha}"))
+	require.False(t, overlap("/x/{id:int}/{a}", "/x/{name:alpha}/{b}"))
+	require.False(t, overlap("/x/{a}", "/x/{path*}"))
+	require.False(t, overlap("/x/{a}.json", "/x/{a}"))
+}
@@ -15,16 +15,18 @@ import (
 	"embed"
 	"errors"
 	"net/http"
-	"regexp"
 	"strings"
 
 	"github.com/go-the-way/anoweb/context"
+	"github.com/go-the-way/anoweb/headers"
+	"github.com/go-the-way/anoweb/middleware"
 	"github.com/go-the-way/anoweb/util"
+	"github.com/go-the-way/anoweb/websocket"
 )
 
 var (
-	dynamicRouteRe   = regexp.MustCompile(`{([^{]+)}`)
 	dynamicParamRep  = `([\w_.-]+)`
+	wildcardRep      = `.+`
 	supportedMethods = []string{
 		http.MethodGet,
 		http.MethodPost,
@@ -38,79 +40,143 @@ var (
 
 // Router struct
 type Router struct {
-	Simples  []*Simple
-	Dynamics []*Dynamic
+	Simples       []*Simple
+	Dynamics      []*Dynamic
+	Mounts        []*Mount
+	registrations map[*Simple]*Registration
+	last          *Registration
+	middlewares   []middleware.Middleware
 }
 
 // NewRouter return new router
 func NewRouter() *Router {
-	return &Router{make([]*Simple, 0), make([]*Dynamic, 0)}
+	return &Router{Simples: make([]*Simple, 0), Dynamics: make([]*Dynamic, 0), Mounts: make([]*Mount, 0), registrations: make(map[*Simple]*Registration)}
 }
 
 // Request Route all Methods
-func (r *Router) Request(pattern string, handler func(ctx *context.Context)) *Router {
-	return r.Route("*", pattern, handler)
+func (r *Router) Request(pattern string, handler func(ctx *context.Context), middlewares ...middleware.Middleware) *Router {
+	return r.Route("*", pattern, handler, middlewares...)
 }
 
 // Get Route Get Method
-func (r *Router) Get(pattern string, handler func(ctx *context.Context)) *Router {
-	return r.Route(http.MethodGet, pattern, handler)
+func (r *Router) Get(pattern string, handler func(ctx *context.Context), middlewares ...middleware.Middleware) *Router {
+	return r.Route(http.MethodGet, pattern, handler, middlewares...)
 }
 
 // Post Route Post Method
-func (r *Router) Post(pattern string, handler func(ctx *context.Context)) *Router {
-	return r.Route(http.MethodPost, pattern, handler)
+func (r *Router) Post(pattern string, handler func(ctx *context.Context), middlewares ...middleware.Middleware) *Router {
+	return r.Route(http.MethodPost, pattern, handler, middlewares...)
 }
 
 // Put Route Put Method
-func (r *Router) Put(pattern string, handler func(ctx *context.Context)) *Router {
-	return r.Route(http.MethodPut, pattern, handler)
+func (r *Router) Put(pattern string, handler func(ctx *context.Context), middlewares ...middleware.Middleware) *Router {
+	return r.Route(http.MethodPut, pattern, handler, middlewares...)
 }
 
 // Delete Route Delete Method
-func (r *Router) Delete(pattern string, handler func(ctx *context.Context)) *Router {
-	return r.Route(http.MethodDelete, pattern, handler)
+func (r *Router) Delete(pattern string, handler func(ctx *context.Context), middlewares ...middleware.Middleware) *Router {
+	return r.Route(http.MethodDelete, pattern, handler, middlewares...)
 }
 
 // Patch Route Patch Method
-func (r *Router) Patch(pattern string, handler func(ctx *context.Context)) *Router {
-	return r.Route(http.MethodPatch, pattern, handler)
+func (r *Router) Patch(pattern string, handler func(ctx *context.Context), middlewares ...middleware.Middleware) *Router {
+	return r.Route(http.MethodPatch, pattern, handler, middlewares...)
 }
 
 // Head Route Head Method
-func (r *Router) Head(pattern string, handler func(ctx *context.Context)) *Router {
-	return r.Route(http.MethodHead, pattern, handler)
+func (r *Router) Head(pattern string, handler func(ctx *context.Context), middlewares ...middleware.Middleware) *Router {
+	return r.Route(http.MethodHead, pattern, handler, middlewares...)
 }
 
 // Options Route Options Method
-func (r *Router) Options(pattern string, handler func(ctx *context.Context)) *Router {
-	return r.Route(http.MethodOptions, pattern, handler)
+func (r *Router) Options(pattern string, handler func(ctx *context.Context), middlewares ...middleware.Middleware) *Router {
+	return r.Route(http.MethodOptions, pattern, handler, middlewares...)
 }
 
 // Resource Route a resource
-func (r *Router) Resource(pattern, file, contentType string) *Router {
+func (r *Router) Resource(pattern, file, contentType string, middlewares ...middleware.Middleware) *Router {
 	return r.Get(pattern, func(ctx *context.Context) {
 		ctx.File(file, contentType)
-	})
+	}, middlewares...)
 }
 
 // FSResource Route a resource
-func (r *Router) FSResource(fs *embed.FS, pattern, file, contentType string) *Router {
+func (r *Router) FSResource(fs *embed.FS, pattern, file, contentType string, middlewares ...middleware.Middleware) *Router {
 	return r.Get(pattern, func(ctx *context.Context) {
 		ctx.FSFile(fs, file, contentType)
+	}, middlewares...)
+}
+
+// Mount Route the std http.Handler for all methods under the prefix, the prefix is not stripped
+func (r *Router) Mount(prefix string, handler http.Handler) *Router {
+	r.Mounts = append(r.Mounts, &Mount{util.TrimSpecialChars(prefix), context.WrapHandler(handler)})
+	return r
+}
+
+// WebSocket Route the WebSocket endpoint, the request is upgraded after the middlewares ran,
+// so the session and params are available, and the connection is closed when the handler returns
+func (r *Router) WebSocket(pattern string, handler func(ctx *context.Context, conn *websocket.Conn), upgrader ...*websocket.Upgrader) *Router {
+	u := &websocket.Upgrader{}
+	if len(upgrader) > 0 && upgrader[0] != nil {
+		u = upgrader[0]
+	}
+	return r.Get(pattern, func(ctx *context.Context) {
+		header := http.Header{}
+		for k, v := range ctx.Response.Header {
+			header[k] = append(header[k], v...)
+		}
+		for _, cookie := range ctx.Response.Cookies {
+			header.Add(headers.SetCookie, cookie.String())
+		}
+		conn, err := u.Upgrade(ctx.Writer(), ctx.Request, header)
+		if err != nil {
+			return
+		}
+		ctx.Status(http.StatusSwitchingProtocols)
+		defer func() { _ = conn.Close() }()
+		handler(ctx, conn)
 	})
 }
 
-// Route Route DIY Method
-func (r *Router) Route(method, pattern string, handler func(ctx *context.Context)) *Router {
+// Route Route DIY Method, the middlewares run after the global, group and router ones
+func (r *Router) Route(method, pattern string, handler func(ctx *context.Context), middlewares ...middleware.Middleware) *Router {
+	return r.route(method, pattern, handler, false, middlewares)
+}
+
+// Override Route DIY Method, replaces the same route, or the overlapping dynamic one (see router.Overlap),
+// registered by other routers, groups or controllers
+// instead of failing the startup with a conflict
+func (r *Router) Override(method, pattern string, handler func(ctx *context.Context), middlewares ...middleware.Middleware) *Router {
+	return r.route(method, pattern, handler, true, middlewares)
+}
+
+// Use adds the middlewares running before all routes and mounts of the router, after the global and group ones
+func (r *Router) Use(middlewares ...middleware.Middleware) *Router {
+	r.middlewares = append(r.middlewares, middlewares...)
+	return r
+}
+
+// Middlewares return router's middlewares
+func (r *Router) Middlewares() []middleware.Middleware {
+	return r.middlewares
+}
+
+func (r *Router) route(method, pattern string, handler func(ctx *context.Context), override bool, middlewares []middleware.Middleware) *Router {
 	r.mustSupport(method)
-	pattern = util.TrimSpecialChars(pattern)
-	if dynamicRouteRe.MatchString(pattern) {
-		r.dynamicRoute(method, pattern, handler)
-	} else {
-		r.simpleRoute(method, pattern, handler)
+	pattern, params := splitPattern(pattern)
+	reg := &Registration{Pattern: expandPattern(pattern, func(i int) string { return "{" + params[i].raw + "}" }), Site: Caller(), Override: override, Middlewares: middlewares}
+	r.last = reg
+	if n := len(params); n > 0 && params[n-1].optional {
+		r.addRoute(method, strings.TrimSuffix(pattern, "/"+placeholder(n-1)), params[:n-1], handler, reg)
 	}
-	return r
+	return r.addRoute(method, pattern, params, handler, reg)
+}
+
+func (r *Router) addRoute(method, pattern string, params []*patternParam, handler func(ctx *context.Context), reg *Registration) *Router {
+	if len(params) > 0 {
+		return r.dynamicRoute(method, pattern, params, handler, reg)
+	}
+	return r.simpleRoute(method, pattern, handler, reg)
 }
 
 func (r *Router) mustSupport(method string) {
@@ -125,7 +191,7 @@ func (r *Router) mustSupport(method string) {
 	panic(errors.New("method not supported : " + method))
 }
 
-func (r *Router) simpleRoute(method, pattern string, handler func(ctx *context.Context)) *Router {
+func (r *Router) simpleRoute(method, pattern string, handler func(ctx *context.Context), reg *Registration) *Router {
 	var methods []string
 	if method == "*" {
 		methods = append(methods, supportedMethods...)
@@ -133,20 +199,19 @@ func (r *Router) simpleRoute(method, pattern string, handler func(ctx *context.C
 		methods = append(methods, method)
 	}
 	for _, m := range methods {
-		r.Simples = append(r.Simples, &Simple{m, pattern, handler})
+		simple := &Simple{m, pattern, handler}
+		r.Simples = append(r.Simples, simple)
+		r.register(simple, reg)
 	}
 	return r
 }
 
-func (r *Router) dynamicRoute(method, pattern string, handler func(ctx *context.Context)) *Router {
-	finds := dynamicRouteRe.FindAllStringSubmatch(pattern, -1)
-	params := make([]string, len(finds))
-	for i, f := range finds {
-		f0 := f[0]
-		f1 := f[1]
-		pattern = strings.Replace(pattern, f0, dynamicParamRep, 1)
-		params[i] = f1
+func (r *Router) dynamicRoute(method, pattern string, patternParams []*patternParam, handler func(ctx *context.Context), reg *Registration) *Router {
+	params := make([]string, len(patternParams))
+	for i, p := range patternParams {
+		params[i] = p.name
 	}
+	pattern = dynamicPattern(pattern, patternParams)
 	methods := make([]string, 0)
 	if method == "*" {
 		methods = append(methods, supportedMethods...)
@@ -154,7 +219,9 @@ func (r *Router) dynamicRoute(method, pattern string, handler func(ctx *context.
 		methods = append(methods, method)
 	}
 	for _, m := range methods {
-		r.Dynamics = append(r.Dynamics, &Dynamic{params, &Simple{m, pattern, handler}})
+		simple := &Simple{m, pattern, handler}
+		r.Dynamics = append(r.Dynamics, &Dynamic{params, simple})
+		r.register(simple, reg)
 	}
 	return r
 }
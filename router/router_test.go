@@ -19,6 +19,7 @@ import (
 	"github.com/go-the-way/anoweb/config"
 	"github.com/go-the-way/anoweb/context"
 	"github.com/go-the-way/anoweb/mime"
+	"github.com/go-the-way/anoweb/websocket"
 
 	"github.com/stretchr/testify/require"
 )
@@ -239,6 +240,7 @@ func TestRouterSimpleAll(t *testing.T) {
 	r := NewRouter()
 	r.Route("*", "/id", func(ctx *context.Context) {})
 	for i, m := range supportedMethods {
+		i, m := i, m
 		t.Run(m, func(t *testing.T) {
 			t.Parallel()
 			require.Equal(t, m, r.Simples[i].Method)
@@ -275,6 +277,7 @@ func TestRouterDynamicAll(t *testing.T) {
 	r := NewRouter()
 	r.Route("*", "/{id}/{name}", func(ctx *context.Context) {})
 	for i, m := range supportedMethods {
+		i, m := i, m
 		t.Run(m, func(t *testing.T) {
 			t.Parallel()
 			require.Equal(t, m, r.Dynamics[i].Method)
@@ -293,3 +296,38 @@ func TestRouterSupport(t *testing.T) {
 	r := NewRouter()
 	r.Route("BODY", "/", func(ctx *context.Context) {})
 }
+
+func TestRouterMount(t *testing.T) {
+	r := NewRouter().Mount("debug//pprof/", http.NotFoundHandler()).Mount("/", http.NotFoundHandler())
+	require.Len(t, r.Mounts, 2)
+	require.Equal(t, "/debug/pprof", r.Mounts[0].Prefix)
+	require.Equal(t, "", r.Mounts[1].Prefix)
+	require.True(t, r.Mounts[1].Match("/any"))
+	require.True(t, r.Mounts[0].Match("/debug/pprof"))
+	require.False(t, r.Mounts[0].Match("/debug/pprofile"))
+}
+
+func TestRouterWebSocket(t *testing.T) {
+	r := NewRouter().WebSocket("/ws", func(ctx *context.Context, conn *websocket.Conn) {}).
+		WebSocket("/ws/{id}", func(ctx *context.Context, conn *websocket.Conn) {}, &websocket.Upgrader{})
+	require.Len(t, r.Simples, 1)
+	require.Equal(t, http.MethodGet, r.Simples[0].Method)
+	require.Len(t, r.Dynamics, 1)
+	require.Equal(t, []string{"id"}, r.Dynamics[0].Params)
+}
+
+func TestRouterUse(t *testing.T) {
+	var trace []string
+	a, b := traceMiddleware(&trace, "a"), traceMiddleware(&trace, "b")
+	r := NewRouter().Use(a).Use(b)
+	require.Len(t, r.Middlewares(), 2)
+	r.Get("/", text("index")).
+		Get("/users/{id}", text("user"), a, b).
+		Resource("/a.txt", "a.txt", mime.TEXT, b).
+		Override(http.MethodPost, "/users", text("users"), a)
+	require.Nil(t, r.Registered(r.Simples[0]).Middlewares)
+	require.Len(t, r.Registered(r.Dynamics[0].Simple).Middlewares, 2)
+	require.Len(t, r.Registered(r.Simples[1]).Middlewares, 1)
+	require.Len(t, r.Registered(r.Simples[2]).Middlewares, 1)
+	require.True(t, r.Registered(r.Simples[2]).Override)
+}
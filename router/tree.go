@@ -0,0 +1,239 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package router
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-the-way/anoweb/context"
+)
+
+const (
+	paramKind = iota
+	regexKind
+	wildcardKind
+)
+
+type (
+	// node is a node of the compressed radix tree of one method,
+	// the static children are tried first, then the params and the wildcards
+	node struct {
+		prefix  string
+		indices string
+		statics []*node
+		params  []*node
+		param   *param
+		route   *treeRoute
+	}
+	// param matches the value of a param node
+	param struct {
+		pattern string
+		kind    int
+		re      *regexp.Regexp
+	}
+	// treeRoute the routed handler with its param names
+	treeRoute struct {
+		params  []string
+		handler func(ctx *context.Context)
+	}
+	// token is a static text or a param of a pattern
+	token struct {
+		static string
+		param  *param
+	}
+)
+
+// lookup return the route matched the path with the param values, nil if none
+func (n *node) lookup(path string, values []string) (*treeRoute, []string) {
+	if path == "" && n.route != nil {
+		return n.route, values
+	}
+	if path != "" {
+		if i := strings.IndexByte(n.indices, path[0]); i >= 0 {
+			if child := n.statics[i]; strings.HasPrefix(path, child.prefix) {
+				if route, vs := child.lookup(path[len(child.prefix):], values); route != nil {
+					return route, vs
+				}
+			}
+		}
+	}
+	for _, child := range n.params {
+		for end, least := child.param.bounds(path); end >= least; end-- {
+			if child.param.match(path[:end]) {
+				if route, vs := child.lookup(path[end:], append(values, path[:end])); route != nil {
+					return route, vs
+				}
+			}
+		}
+	}
+	return nil, values
+}
+
+// insert adds the route at the end of the tokens, replaces the existing one
+func (n *node) insert(tokens []token, route *treeRoute) {
+	for _, t := range tokens {
+		if t.param == nil {
+			n = n.insertStatic(t.static)
+		} else {
+			n = n.insertParam(t.param)
+		}
+	}
+	n.route = route
+}
+
+func (n *node) insertStatic(s string) *node {
+	for s != "" {
+		i := strings.IndexByte(n.indices, s[0])
+		if i < 0 {
+			child := &node{prefix: s}
+			n.indices += s[:1]
+			n.statics = append(n.statics, child)
+			return child
+		}
+		child := n.statics[i]
+		l := 0
+		for l < len(s) && l < len(child.prefix) && s[l] == child.prefix[l] {
+			l++
+		}
+		if l < len(child.prefix) {
+			split := *child
+			split.prefix = child.prefix[l:]
+			*child = node{prefix: child.prefix[:l], indices: split.prefix[:1], statics: []*node{&split}}
+		}
+		n, s = child, s[l:]
+	}
+	return n
+}
+
+func (n *node) insertParam(p *param) *node {
+	for _, child := range n.params {
+		if child.param.kind == p.kind && child.param.pattern == p.pattern {
+			return child
+		}
+	}
+	child := &node{param: p}
+	n.params = append(n.params, child)
+	sort.SliceStable(n.params, func(i, j int) bool {
+		pi, pj := n.params[i].param, n.params[j].param
+		if pi.kind != pj.kind {
+			return pi.kind == regexKind || pj.kind == wildcardKind
+		}
+		return pi.pattern < pj.pattern
+	})
+	return child
+}
+
+// bounds return the longest and the shortest length of the value at the head of the path,
+// the params match in a path segment, the wildcards match the rest of the path
+func (p *param) bounds(path string) (int, int) {
+	switch p.kind {
+	case paramKind:
+		end := 0
+		for end < len(path) && paramChar(path[end]) {
+			end++
+		}
+		return end, 1
+	case regexKind:
+		if end := strings.IndexByte(path, '/'); end >= 0 {
+			return end, 1
+		}
+		return len(path), 1
+	}
+	if p.pattern == ".+" {
+		return len(path), 1
+	}
+	return len(path), 0
+}
+
+func (p *param) match(value string) bool {
+	return p.re == nil || p.re.MatchString(value)
+}
+
+// paramChar reports whether the char matches the default param `[\w_.-]`
+func paramChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.' || c == '-'
+}
+
+func newParam(pattern string) *param {
+	switch pattern {
+	case dynamicParamRep[1 : len(dynamicParamRep)-1]:
+		return &param{pattern: pattern, kind: paramKind}
+	case ".*", ".+":
+		return &param{pattern: pattern, kind: wildcardKind}
+	}
+	return &param{pattern: pattern, kind: regexKind, re: regexp.MustCompile("^(?:" + pattern + ")$")}
+}
+
+// parsePattern splits the parsed dynamic pattern `^/static/(param)$` into tokens,
+// every top-level group is a param, the escaped chars of the static text are unescaped
+func parsePattern(pattern string) []token {
+	pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "^"), "$")
+	tokens := make([]token, 0)
+	var static strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				static.WriteByte(pattern[i])
+			}
+		case '(':
+			end := groupEnd(pattern, i)
+			if static.Len() > 0 {
+				tokens = append(tokens, token{static: static.String()})
+				static.Reset()
+			}
+			tokens = append(tokens, token{param: newParam(pattern[i+1 : end])})
+			i = end
+		default:
+			static.WriteByte(c)
+		}
+	}
+	if static.Len() > 0 {
+		tokens = append(tokens, token{static: static.String()})
+	}
+	return tokens
+}
+
+func paramCount(tokens []token) int {
+	count := 0
+	for _, t := range tokens {
+		if t.param != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// groupEnd return the index of the `)` closing the group opened at the start
+func groupEnd(pattern string, start int) int {
+	depth, class := 0, false
+	for i := start; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '\\':
+			i++
+		case class:
+			class = c != ']'
+		case c == '[':
+			class = true
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	panic("router: unclosed group in pattern " + pattern)
+}
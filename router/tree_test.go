@@ -0,0 +1,200 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/go-the-way/anoweb/config"
+	"github.com/go-the-way/anoweb/context"
+
+	"github.com/stretchr/testify/require"
+)
+
+func parsed(r *Router) *ParsedRouter {
+	pr := &ParsedRouter{Simples: SimpleM{}, Dynamics: DynamicM{}}
+	for _, s := range r.Simples {
+		pr.Simples[s.Method+":"+s.Pattern] = s
+	}
+	for _, d := range r.Dynamics {
+		if _, have := pr.Dynamics[d.Method]; !have {
+			pr.Dynamics[d.Method] = map[string]*Dynamic{}
+		}
+		pr.Dynamics[d.Method]["^"+d.Pattern+"$"] = d
+	}
+	return pr
+}
+
+func text(s string) func(ctx *context.Context) {
+	return func(ctx *context.Context) { ctx.Text(s) }
+}
+
+func serveParsed(pr *ParsedRouter, method, path string) *context.Context {
+	ctx := context.New()
+	ctx.Allocate(httptest.NewRequest(method, path, nil), &config.Template{})
+	if handler := pr.Handler(ctx); handler != nil {
+		handler(ctx)
+	}
+	return ctx
+}
+
+func TestTreePriority(t *testing.T) {
+	r := NewRouter().
+		Get("/users/new", text("new")).
+		Get("/users/{id}", text("id")).
+		Get("/users/{id}/books", text("books")).
+		Get("/users/new/{name}", text("new-name"))
+	pr := parsed(r)
+	pr.Dynamics[http.MethodGet][`^/users/(.*)$`] = &Dynamic{[]string{"path"}, &Simple{http.MethodGet, "", text("wildcard")}}
+
+	for i := 0; i < 20; i++ {
+		for path, expect := range map[string]string{
+			"/users/new":       "new",
+			"/users/1":         "id",
+			"/users/1/books":   "books",
+			"/users/new/books": "new-name",
+			"/users/1/a/b":     "wildcard",
+		} {
+			require.Equal(t, expect, string(serveParsed(pr, http.MethodGet, path).Response.Data), path)
+		}
+	}
+	require.Equal(t, "1", serveParsed(pr, http.MethodGet, "/users/1/books").Param("id"))
+	require.Equal(t, "books", serveParsed(pr, http.MethodGet, "/users/new/books").Param("name"))
+	require.Equal(t, "1/a/b", serveParsed(pr, http.MethodGet, "/users/1/a/b").Param("path"))
+}
+
+func TestTreeParams(t *testing.T) {
+	pr := parsed(NewRouter().
+		Get("/files/{name}.json", text("json")).
+		Get("/orders/{id}", text("id")).
+		Get("/{a}/{b}/{c}", text("abc")))
+	pr.Dynamics[http.MethodGet][`^/orders/(\d+)$`] = &Dynamic{[]string{"no"}, &Simple{http.MethodGet, "", text("no")}}
+
+	ctx := serveParsed(pr, http.MethodGet, "/files/a.b.json")
+	require.Equal(t, "json", string(ctx.Response.Data))
+	require.Equal(t, "a.b", ctx.Param("name"))
+	ctx = serveParsed(pr, http.MethodGet, "/orders/12")
+	require.Equal(t, "no", string(ctx.Response.Data))
+	require.Equal(t, "12", ctx.Param("no"))
+	ctx = serveParsed(pr, http.MethodGet, "/orders/a12")
+	require.Equal(t, "id", string(ctx.Response.Data))
+	require.Equal(t, "a12", ctx.Param("id"))
+	ctx = serveParsed(pr, http.MethodGet, "/x/y/z")
+	require.Equal(t, "abc", string(ctx.Response.Data))
+	require.Equal(t, []string{"x", "y", "z"}, []string{ctx.Param("a"), ctx.Param("b"), ctx.Param("c")})
+	for _, path := range []string{"/files/.json", "/files/a%20b.json", "/x/y", "/x/y/z/w"} {
+		require.Equal(t, http.StatusNotFound, serveParsed(pr, http.MethodGet, path).Response.Status, path)
+	}
+}
+
+func TestTreeMismatchedParams(t *testing.T) {
+	pr := parsed(NewRouter())
+	pr.Dynamics[http.MethodGet] = map[string]*Dynamic{`^/skip/([\w_.-]+)$`: {Params: []string{"a", "b"}, Simple: &Simple{http.MethodGet, "", text("skip")}}}
+	require.EqualError(t, pr.Build(), "router: the 1 groups of pattern ^/skip/([\\w_.-]+)$ mismatch the params [a b]")
+	require.Equal(t, pr.Build(), pr.Build())
+	require.NotEqual(t, "skip", string(serveParsed(pr, http.MethodGet, "/skip/a").Response.Data))
+	pr = parsed(NewRouter())
+	pr.Dynamics[http.MethodGet] = map[string]*Dynamic{`^/bad/([a-z+)$`: {Params: []string{"a"}, Simple: &Simple{http.MethodGet, "", text("bad")}}}
+	require.Error(t, pr.Build())
+}
+
+func TestTreeInsertStatic(t *testing.T) {
+	root := &node{}
+	paths := []string{"/search", "/support", "/s", "", "/src/a", "/search/all"}
+	for i, path := range paths {
+		root.insert([]token{{static: path}}, &treeRoute{params: []string{fmt.Sprint(i)}})
+	}
+	for i, path := range paths {
+		route, _ := root.lookup(path, nil)
+		require.NotNil(t, route, path)
+		require.Equal(t, []string{fmt.Sprint(i)}, route.params, path)
+	}
+	for _, path := range []string{"/se", "/sr", "/searc", "/search/", "/x"} {
+		route, _ := root.lookup(path, nil)
+		require.Nil(t, route, path)
+	}
+	require.Equal(t, "/s", root.statics[0].prefix)
+	require.Len(t, root.statics, 1)
+}
+
+func TestParsePattern(t *testing.T) {
+	tokens := parsePattern(`^/a/([\w_.-]+)\.json/(\d+(?:-\d+)?)/([)]+)/(.*)$`)
+	require.Len(t, tokens, 8)
+	require.Equal(t, "/a/", tokens[0].static)
+	require.Equal(t, paramKind, tokens[1].param.kind)
+	require.Equal(t, ".json/", tokens[2].static)
+	require.Equal(t, regexKind, tokens[3].param.kind)
+	require.Equal(t, `\d+(?:-\d+)?`, tokens[3].param.pattern)
+	require.Equal(t, `[)]+`, tokens[5].param.pattern)
+	require.Equal(t, wildcardKind, tokens[7].param.kind)
+	require.Equal(t, 4, paramCount(tokens))
+	require.Panics(t, func() { parsePattern(`^/a/(b$`) })
+}
+
+func TestTreeSimpleZeroAlloc(t *testing.T) {
+	pr := parsed(NewRouter().Get("/api/v1/users", text("users")).Get("/api/v1/users/{id}", text("user")))
+	ctx := context.New()
+	ctx.Allocate(httptest.NewRequest(http.MethodGet, "/api/v1/users", nil), &config.Template{})
+	require.Zero(t, testing.AllocsPerRun(100, func() { pr.Handler(ctx) }))
+}
+
+// regexLookup the former lookup compiling the dynamic patterns on every request, for the benchmarks
+func regexLookup(pr *ParsedRouter, method, reqPath string) func(ctx *context.Context) {
+	if simple, have := pr.Simples[fmt.Sprintf("%s:%s", method, reqPath)]; have {
+		return simple.Handler
+	}
+	for k, v := range pr.Dynamics[method] {
+		if finds := regexp.MustCompile(k).FindAllStringSubmatch(reqPath, -1); len(finds) > 0 && len(finds[0]) == len(v.Params)+1 {
+			return v.Handler
+		}
+	}
+	return nil
+}
+
+func benchmarkRouter() *ParsedRouter {
+	r := NewRouter()
+	for i := 0; i < 100; i++ {
+		r.Get(fmt.Sprintf("/api/v1/resource%d", i), text("list")).
+			Get(fmt.Sprintf("/api/v1/resource%d/{id}", i), text("get")).
+			Get(fmt.Sprintf("/api/v1/resource%d/{id}/items", i), text("items")).
+			Get(fmt.Sprintf("/api/v1/resource%d/{id}/items/{item}", i), text("item"))
+	}
+	return parsed(r)
+}
+
+func BenchmarkParsedRouter(b *testing.B) {
+	pr := benchmarkRouter()
+	paths := map[string]string{"static": "/api/v1/resource99", "dynamic": "/api/v1/resource99/42/items/7"}
+	for name, path := range paths {
+		path := path
+		b.Run("regex/"+name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if regexLookup(pr, http.MethodGet, path) == nil {
+					b.Fatal(path)
+				}
+			}
+		})
+		b.Run("tree/"+name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if route, _ := pr.lookup(http.MethodGet, path); route == nil {
+					b.Fatal(path)
+				}
+			}
+		})
+	}
+}
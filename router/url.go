@@ -0,0 +1,116 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ErrRouteNotFound no route registered with the name
+var ErrRouteNotFound = errors.New("router: route not found")
+
+// urlPattern the split pattern of a named route, for building the URLs
+type urlPattern struct {
+	pattern string
+	params  []*patternParam
+	res     []*regexp.Regexp
+}
+
+// Name names the route registered last, for building its URL with App.URL
+func (r *Router) Name(name string) *Router {
+	if r.last == nil {
+		panic(errors.New("router: no route to name " + name))
+	}
+	r.last.Name = name
+	return r
+}
+
+// URL return the path of the route with the params, the params are pairs of name and value,
+// the values of the path params are escaped and must match the param,
+// the other params are added as the query string
+func (reg *Registration) URL(params ...interface{}) (string, error) {
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("router: odd params of route %s", reg.Name)
+	}
+	reg.once.Do(reg.split)
+	values := make(map[string]string, len(params)/2)
+	query := url.Values{}
+	for i := 0; i < len(params); i += 2 {
+		name, ok := params[i].(string)
+		if !ok {
+			return "", fmt.Errorf("router: param name %v of route %s is not string", params[i], reg.Name)
+		}
+		value := fmt.Sprint(params[i+1])
+		if reg.url.has(name) {
+			values[name] = value
+		} else {
+			query.Add(name, value)
+		}
+	}
+	pattern := reg.url.pattern
+	if n := len(reg.url.params); n > 0 && reg.url.params[n-1].optional && values[reg.url.params[n-1].name] == "" {
+		pattern = strings.TrimSuffix(pattern, "/"+placeholder(n-1))
+	}
+	var err error
+	path := expandPattern(pattern, func(i int) string {
+		p := reg.url.params[i]
+		value, have := values[p.name]
+		switch {
+		case err != nil:
+		case !have:
+			err = fmt.Errorf("router: missing param %s of route %s", p.name, reg.Name)
+		case !reg.url.res[i].MatchString(value):
+			err = fmt.Errorf("router: invalid param %s=%s of route %s", p.name, value, reg.Name)
+		case p.regex == wildcardRep:
+			segments := strings.Split(value, "/")
+			for j, s := range segments {
+				segments[j] = url.PathEscape(s)
+			}
+			return strings.Join(segments, "/")
+		default:
+			return url.PathEscape(value)
+		}
+		return ""
+	})
+	if err != nil {
+		return "", err
+	}
+	if path == "" {
+		path = "/"
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return path, nil
+}
+
+func (reg *Registration) split() {
+	pattern, params := splitPattern(reg.Pattern)
+	res := make([]*regexp.Regexp, len(params))
+	for i, p := range params {
+		res[i] = regexp.MustCompile("^(?:" + p.regex + ")$")
+	}
+	reg.url = &urlPattern{pattern, params, res}
+}
+
+func (u *urlPattern) has(name string) bool {
+	for _, p := range u.params {
+		if p.name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package router
+
+import (
+	"testing"
+
+	"github.com/go-the-way/anoweb/context"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouterName(t *testing.T) {
+	h := func(ctx *context.Context) {}
+	r := NewRouter().Get("/users", h).Name("users").Post("/users", h)
+	require.Equal(t, "users", r.Registered(r.Simples[0]).Name)
+	require.Equal(t, "", r.Registered(r.Simples[1]).Name)
+	require.Panics(t, func() { NewRouter().Name("none") })
+}
+
+func TestRegistrationURL(t *testing.T) {
+	for _, c := range []struct {
+		pattern string
+		params  []interface{}
+		expect  string
+	}{
+		{"", nil, "/"},
+		{"/users", nil, "/users"},
+		{"/users/{id:int}", []interface{}{"id", 5}, "/users/5"},
+		{"/users/{id}/books", []interface{}{"id", "a.b", "page", 2, "tag", "x y", "tag", "z"}, "/users/a.b/books?page=2&tag=x+y&tag=z"},
+		{"/slugs/{slug:[\\p{L}-]+}", []interface{}{"slug", "héllo"}, "/slugs/h%C3%A9llo"},
+		{"/files/{path*}", []interface{}{"path", "a b/c?.txt"}, "/files/a%20b/c%3F.txt"},
+		{"/posts/{page:int?}", nil, "/posts"},
+		{"/posts/{page:int?}", []interface{}{"page", ""}, "/posts"},
+		{"/posts/{page:int?}", []interface{}{"page", 3}, "/posts/3"},
+		{"/v/{a}-{b}", []interface{}{"b", 2, "a", 1}, "/v/1-2"},
+	} {
+		url, err := (&Registration{Pattern: c.pattern}).URL(c.params...)
+		require.Nil(t, err, c.pattern)
+		require.Equal(t, c.expect, url, c.pattern)
+	}
+
+	reg := &Registration{Pattern: "/users/{id:int}", Name: "user"}
+	for _, c := range []struct {
+		params []interface{}
+		err    string
+	}{
+		{[]interface{}{"id"}, "router: odd params of route user"},
+		{[]interface{}{1, 1}, "router: param name 1 of route user is not string"},
+		{nil, "router: missing param id of route user"},
+		{[]interface{}{"page", 1}, "router: missing param id of route user"},
+		{[]interface{}{"id", "abc"}, "router: invalid param id=abc of route user"},
+	} {
+		_, err := reg.URL(c.params...)
+		require.EqualError(t, err, c.err)
+	}
+}
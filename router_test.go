@@ -13,14 +13,19 @@ package anoweb
 
 import (
 	"embed"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/go-the-way/anoweb/config"
 	"github.com/go-the-way/anoweb/context"
 	"github.com/go-the-way/anoweb/mime"
 	"github.com/go-the-way/anoweb/router"
+	"github.com/go-the-way/anoweb/websocket"
 	"github.com/stretchr/testify/require"
 )
 
@@ -310,3 +315,312 @@ func TestAddRouterGroup(t *testing.T) {
 		require.Equal(t, 1, len(a.parsedRouters.Simples))
 	}
 }
+
+func TestAppNestedGroups(t *testing.T) {
+	var trace []string
+	mw := func(name string) _traceMiddleware { return _traceMiddleware{&trace, name} }
+	handler := func(ctx *context.Context) {
+		trace = append(trace, "handler")
+		ctx.Text(ctx.Request.URL.Path)
+	}
+	root := router.NewGroup("/").Add(router.NewRouter().Get("/", handler))
+	api := root.Group("/api/").Use(mw("api")).Meta("auth", true).
+		NotFound(func(ctx *context.Context) { ctx.Text("api not found") }).
+		Add(router.NewRouter().Get("/status", handler))
+	v1 := api.Group("v1").Use(mw("v1")).Meta("version", 1).
+		Add(router.NewRouter().Use(mw("router")).Get("/users/{id}", handler, mw("route")))
+	v1.Group("").Add(router.NewRouter().Post("/users", handler))
+	a := New().Use(mw("global")).AddRouterGroup(root).parseRouters()
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		trace = nil
+		rec := httptest.NewRecorder()
+		a.newDispatcher().ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+	for path, expect := range map[string][]string{
+		"/":               {"global", "handler"},
+		"/api/status":     {"global", "api", "handler"},
+		"/api/v1/users/1": {"global", "api", "v1", "router", "route", "handler"},
+	} {
+		rec := serve(http.MethodGet, path)
+		require.Equal(t, path, rec.Body.String())
+		require.Equal(t, expect, trace, path)
+	}
+	require.Equal(t, "/api/v1/users", serve(http.MethodPost, "/api/v1/users").Body.String())
+	require.Equal(t, []string{"global", "api", "v1", "handler"}, trace)
+	rec := serve(http.MethodGet, "/api/v1/none")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	require.Equal(t, "api not found", rec.Body.String())
+	require.Equal(t, "Not Found", serve(http.MethodGet, "/none").Body.String())
+
+	routes := a.Routes()
+	require.Len(t, routes, 4)
+	require.Nil(t, routes[0].Metadata)
+	require.Equal(t, "/api/status", routes[1].Pattern)
+	require.Equal(t, map[string]interface{}{"auth": true}, routes[1].Metadata)
+	require.Equal(t, "/api/v1", routes[2].Group)
+	require.Equal(t, map[string]interface{}{"auth": true, "version": 1}, routes[3].Metadata)
+}
+
+func TestAppMount(t *testing.T) {
+	g := router.NewGroup("/api").Add(router.NewRouter().Mount("/std", http.NotFoundHandler()))
+	a := New().Mount("/std", http.NotFoundHandler()).AddRouterGroup(g).parseRouters()
+	require.Len(t, a.parsedRouters.Mounts, 2)
+	require.Equal(t, "/api/std", a.parsedRouters.Mounts[0].Prefix)
+	require.Equal(t, "/std", a.parsedRouters.Mounts[1].Prefix)
+}
+
+type testCookieMW struct{}
+
+func (testCookieMW) Handler() func(ctx *context.Context) {
+	return func(ctx *context.Context) {
+		ctx.AddCookie(&http.Cookie{Name: "sid", Value: "abc"})
+		ctx.Chain()
+	}
+}
+
+func TestAppWebSocket(t *testing.T) {
+	a := New().Use(testCookieMW{}).WebSocket("/ws/{id}", func(ctx *context.Context, conn *websocket.Conn) {
+		_ = conn.WriteText("hello " + ctx.Param("id"))
+		for {
+			mt, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			_ = conn.WriteMessage(mt, data)
+		}
+	})
+	s := httptest.NewServer(a.Handler())
+	defer s.Close()
+
+	conn, resp, err := websocket.Dial("ws"+strings.TrimPrefix(s.URL, "http")+"/ws/10", nil)
+	require.Nil(t, err)
+	defer func() { _ = conn.Close() }()
+	require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	require.Len(t, resp.Cookies(), 1)
+	require.Equal(t, "abc", resp.Cookies()[0].Value)
+	_, data, err := conn.ReadMessage()
+	require.Nil(t, err)
+	require.Equal(t, "hello 10", string(data))
+	require.Nil(t, conn.WriteText("echo"))
+	_, data, err = conn.ReadMessage()
+	require.Nil(t, err)
+	require.Equal(t, "echo", string(data))
+
+	r, err := http.Get(s.URL + "/ws/10")
+	require.Nil(t, err)
+	_ = r.Body.Close()
+	require.Equal(t, http.StatusBadRequest, r.StatusCode)
+}
+
+func TestAppNotFound(t *testing.T) {
+	serve := func(a *App, method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		a.newDispatcher().ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+	{
+		a := New().Get("/", func(ctx *context.Context) { ctx.Text("index") }).parseRouters()
+		require.Equal(t, http.StatusNotFound, serve(a, http.MethodGet, "/none").Code)
+		rec := serve(a, http.MethodPost, "/")
+		require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+		require.Equal(t, "GET, HEAD, OPTIONS", rec.Header().Get("Allow"))
+		rec = serve(a, http.MethodOptions, "/")
+		require.Equal(t, http.StatusNoContent, rec.Code)
+		require.Equal(t, "GET, HEAD, OPTIONS", rec.Header().Get("Allow"))
+		rec = serve(a, http.MethodHead, "/")
+		require.Equal(t, http.StatusOK, rec.Code)
+	}
+	{
+		g := router.NewGroup("/api").Add(router.NewRouter().Get("/users", func(ctx *context.Context) {})).
+			NotFound(func(ctx *context.Context) { ctx.JSON(map[string]string{"error": "not found"}) }).
+			MethodNotAllowed(func(ctx *context.Context) { ctx.JSON(map[string]string{"error": "not allowed"}) })
+		a := New().AddRouterGroup(g).
+			NotFound(func(ctx *context.Context) { ctx.Text("page not found") }).
+			MethodNotAllowed(func(ctx *context.Context) { ctx.Text("method not allowed") }).
+			Get("/", func(ctx *context.Context) {}).parseRouters()
+		rec := serve(a, http.MethodGet, "/api/none")
+		require.Equal(t, http.StatusNotFound, rec.Code)
+		require.Equal(t, `{"error":"not found"}`, rec.Body.String())
+		rec = serve(a, http.MethodDelete, "/api/users")
+		require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+		require.Equal(t, `{"error":"not allowed"}`, rec.Body.String())
+		rec = serve(a, http.MethodGet, "/none")
+		require.Equal(t, http.StatusNotFound, rec.Code)
+		require.Equal(t, "page not found", rec.Body.String())
+		rec = serve(a, http.MethodPut, "/")
+		require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+		require.Equal(t, "method not allowed", rec.Body.String())
+	}
+}
+
+func TestAppRouteConflict(t *testing.T) {
+	h := func(ctx *context.Context) {}
+	conflict := func(a *App) *router.ConflictError {
+		err := a.prepare()
+		if err == nil {
+			return nil
+		}
+		require.Same(t, err, a.prepare())
+		return err.(*router.ConflictError)
+	}
+	{
+		_, file, line, _ := runtime.Caller(0)
+		a := New().Get("/users", h).
+			Get("/users/", h)
+		err := conflict(a)
+		require.NotNil(t, err)
+		require.Equal(t, http.MethodGet, err.Method)
+		require.Equal(t, fmt.Sprintf("%s:%d", file, line+1), err.Existing.Site)
+		require.Equal(t, fmt.Sprintf("%s:%d", file, line+2), err.Route.Site)
+		require.Contains(t, err.Error(), fmt.Sprintf("router_test.go:%d", line+1))
+		require.Contains(t, err.Error(), fmt.Sprintf("router_test.go:%d", line+2))
+		require.Panics(t, func() { a.Handler() })
+	}
+	{
+		g := router.NewGroup("/api").Add(router.NewRouter().Delete("/users/{id}", h))
+		a := New().AddRouterGroup(g).Delete("/api/users/{name}", h)
+		err := conflict(a)
+		require.NotNil(t, err)
+		require.Equal(t, "/api/users/{name}", err.Route.Pattern)
+		require.Equal(t, "/api/users/{id}", err.Existing.Pattern)
+	}
+	{
+		_, file, line, _ := runtime.Caller(0)
+		a := New().Controller(&_controller{}).
+			Put("/_/{id}", h)
+		err := conflict(a)
+		require.NotNil(t, err)
+		require.Equal(t, fmt.Sprintf("%s:%d", file, line+1), err.Route.Site)
+		require.Equal(t, fmt.Sprintf("%s:%d", file, line+2), err.Existing.Site)
+	}
+	{
+		a := New().Request("/any", h).Get("/users/{id}", h).Get("/users/new", h).Get("/users/{id}/books", h)
+		require.Nil(t, conflict(a))
+	}
+	{
+		a := New().Override(http.MethodGet, "/users", func(ctx *context.Context) { ctx.Text("override") }).
+			AddRouter(router.NewRouter().Get("/users", h)).
+			AddRouterGroup(router.NewGroup("").Add(router.NewRouter().Get("/users", h)))
+		require.Nil(t, conflict(a))
+		rec := httptest.NewRecorder()
+		a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+		require.Equal(t, "override", rec.Body.String())
+	}
+	{
+		a := New().Override(http.MethodGet, "/users", h).Override(http.MethodGet, "/users", h)
+		require.NotNil(t, conflict(a))
+	}
+	{
+		a := New().Get("/x/{a}", h).Get("/x/{b:[a-z]+}", h)
+		err := conflict(a)
+		require.NotNil(t, err)
+		require.Equal(t, "/x/{b:[a-z]+}", err.Route.Pattern)
+		require.Equal(t, "/x/{a}", err.Existing.Pattern)
+	}
+	{
+		a := New().Get("/x/{a}", h).Get("/x/{path*}", h).Post("/x/{id:int}", h).Get("/x/{a}.json", h)
+		require.Nil(t, conflict(a))
+	}
+	{
+		a := New().Get("/users/{id:int}", func(ctx *context.Context) { ctx.Text("id " + ctx.Param("id")) }).
+			Get("/users/{name:alpha}", func(ctx *context.Context) { ctx.Text("name " + ctx.Param("name")) })
+		require.Nil(t, conflict(a))
+		for path, body := range map[string]string{"/users/1": "id 1", "/users/abc": "name abc"} {
+			rec := httptest.NewRecorder()
+			a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			require.Equal(t, body, rec.Body.String())
+		}
+		err := conflict(New().Get("/users/{id:int}", h).Get("/users/{name:alpha}", h).Get("/users/{user}", h))
+		require.NotNil(t, err)
+		require.Equal(t, "/users/{user}", err.Route.Pattern)
+		require.Equal(t, "/users/{id:int}", err.Existing.Pattern)
+		require.NotNil(t, conflict(New().Get("/users/{id:int}", h).Get("/users/{no:[0-9]+}", h)))
+	}
+	{
+		a := New().Get("/x/{a}", h).
+			Override(http.MethodGet, "/x/{id:int}", func(ctx *context.Context) { ctx.Text("override " + ctx.Param("id")) })
+		require.Nil(t, conflict(a))
+		routes := a.Routes()
+		require.Len(t, routes, 1)
+		require.Equal(t, "/x/{id:int}", routes[0].Pattern)
+		rec := httptest.NewRecorder()
+		a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/x/1", nil))
+		require.Equal(t, "override 1", rec.Body.String())
+		rec = httptest.NewRecorder()
+		a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/x/abc", nil))
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestAppTypedParams(t *testing.T) {
+	a := New().
+		Get("/orders/{id:int}", context.Handle(func(ctx *context.Context) error {
+			id, err := ctx.IntParamE("id")
+			if err != nil {
+				return err
+			}
+			ctx.Text(fmt.Sprint(id * 2))
+			return nil
+		})).
+		Get("/static/{path*}", func(ctx *context.Context) { ctx.Text(ctx.Param("path")) }).
+		Get("/pages/{n?}", context.Handle(func(ctx *context.Context) error {
+			n, err := ctx.IntParamE("n")
+			if err != nil {
+				return err
+			}
+			ctx.Text(fmt.Sprint(n))
+			return nil
+		}))
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+	require.Equal(t, "42", serve("/orders/21").Body.String())
+	require.Equal(t, http.StatusNotFound, serve("/orders/abc").Code)
+	require.Equal(t, "css/site.css", serve("/static/css/site.css").Body.String())
+	require.Equal(t, "3", serve("/pages/3").Body.String())
+	require.Equal(t, http.StatusBadRequest, serve("/pages/x").Code)
+	rec := serve("/pages")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Contains(t, rec.Body.String(), "Missing param n")
+}
+
+func TestAppURL(t *testing.T) {
+	h := func(ctx *context.Context) {}
+	g := router.NewGroup("/api/v1").Add(router.NewRouter().Get("/users/{id:int}", h).Name("user").Put("/users/{id:int}", h).Name("user"))
+	a := New().AddRouterGroup(g).
+		Get("/", func(ctx *context.Context) { ctx.Template(`<a href="{{url "user" "id" 7 "tab" "a&b"}}">`, nil) }).Name("index").
+		Get("/old", context.Handle(func(ctx *context.Context) error { return ctx.RedirectToRoute("index") })).
+		Get("/broken", context.Handle(func(ctx *context.Context) error { return ctx.RedirectToRoute("none") }))
+	_, err := a.URL("index")
+	require.True(t, errors.Is(err, router.ErrRouteNotFound))
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+	require.Equal(t, `<a href="/api/v1/users/7?tab=a%26b">`, serve("/").Body.String())
+	rec := serve("/old")
+	require.Equal(t, http.StatusTemporaryRedirect, rec.Code)
+	require.Equal(t, "/", rec.Header().Get("Location"))
+	require.Equal(t, http.StatusInternalServerError, serve("/broken").Code)
+	url, err := a.URL("user", "id", 1)
+	require.Nil(t, err)
+	require.Equal(t, "/api/v1/users/1", url)
+	_, err = a.URL("none")
+	require.EqualError(t, err, "router: route not found: none")
+
+	a = New().Get("/a", h).Name("dup").Get("/b", h).Name("dup")
+	require.Contains(t, a.prepare().Error(), "router: route name dup of /b")
+}
+
+func TestAppRouteBuildError(t *testing.T) {
+	a := New().Get("/a/{id}", func(ctx *context.Context) {})
+	a.parsedRouters.Dynamics[http.MethodGet] = map[string]*router.Dynamic{
+		`^/b/([\w_.-]+)$`: {Params: []string{"a", "b"}, Simple: &router.Simple{Method: http.MethodGet, Handler: func(ctx *context.Context) {}}},
+	}
+	require.EqualError(t, a.prepare(), "router: the 1 groups of pattern ^/b/([\\w_.-]+)$ mismatch the params [a b]")
+}
@@ -0,0 +1,112 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anoweb
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/go-the-way/anoweb/context"
+	"github.com/go-the-way/anoweb/middleware"
+)
+
+// RouteInfo describes a parsed route
+type RouteInfo struct {
+	// Method the route method
+	Method string `json:"method"`
+	// Pattern the full pattern with the group prefix
+	Pattern string `json:"pattern"`
+	// Params the path param names
+	Params []string `json:"params,omitempty"`
+	// Name the route name
+	Name string `json:"name,omitempty"`
+	// Handler the handler function name
+	Handler string `json:"handler"`
+	// Group the group prefix
+	Group string `json:"group,omitempty"`
+	// Middlewares the middleware type names, in the running order
+	Middlewares []string `json:"middlewares,omitempty"`
+	// Site the file:line registered the route
+	Site string `json:"site,omitempty"`
+	// Metadata the metadata of the group
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Routes return the parsed routes sorted by pattern and method, the mounts are not included.
+// The routes are parsed once the App is prepared by Handler, Run or Start
+func (a *App) Routes() []*RouteInfo {
+	var middlewares []string
+	for _, m := range a.Middlewares() {
+		middlewares = append(middlewares, middlewareName(m))
+	}
+	routes := make([]*RouteInfo, 0, len(a.routes))
+	seen := make(map[string]*RouteInfo, len(a.routes))
+	for _, r := range a.routes {
+		// the optional param routes share the pattern
+		key := r.Method + " " + r.Pattern
+		if s, have := seen[key]; have {
+			if len(r.Params) > len(s.Params) {
+				s.Params = r.Params
+			}
+			continue
+		}
+		route := *r
+		route.Middlewares = append(middlewares[:len(middlewares):len(middlewares)], r.Middlewares...)
+		seen[key] = &route
+		routes = append(routes, &route)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Pattern != routes[j].Pattern {
+			return routes[i].Pattern < routes[j].Pattern
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// DebugRoutes Route the JSON of Routes on GET pattern, for debugging only
+func (a *App) DebugRoutes(pattern string) *App {
+	return a.Get(pattern, func(ctx *context.Context) { ctx.JSON(a.Routes()) })
+}
+
+// printRoutes prints the routes table if Config.Banner.Routes is enabled
+func (a *App) printRoutes() {
+	if a.Config.Banner.Routes {
+		_ = a.writeRoutes(os.Stdout)
+	}
+}
+
+func (a *App) writeRoutes(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "METHOD\tPATTERN\tNAME\tHANDLER")
+	for _, r := range a.Routes() {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Method, r.Pattern, r.Name, r.Handler)
+	}
+	return tw.Flush()
+}
+
+func funcName(f interface{}) string {
+	if fn := runtime.FuncForPC(reflect.ValueOf(f).Pointer()); fn != nil {
+		return fn.Name()
+	}
+	return ""
+}
+
+func middlewareName(m middleware.Middleware) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", m), "*")
+}
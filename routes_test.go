@@ -0,0 +1,82 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anoweb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/go-the-way/anoweb/context"
+	"github.com/go-the-way/anoweb/router"
+
+	"github.com/stretchr/testify/require"
+)
+
+func routesUsersHandler(ctx *context.Context) {}
+
+func TestAppRoutes(t *testing.T) {
+	_, file, line, _ := runtime.Caller(0)
+	g := router.NewGroup("/api").Use(_authMiddleware{}).Add(router.NewRouter().Get("/users/{id:int}", routesUsersHandler).Name("user"))
+	a := New().AddRouterGroup(g).
+		Request("/any", routesUsersHandler).
+		Get("/posts/{page?}", routesUsersHandler).
+		Use(&_middleware{})
+	require.Empty(t, a.Routes())
+	require.Nil(t, a.prepare())
+
+	routes := a.Routes()
+	require.Len(t, routes, 9)
+	for i, m := range []string{"DELETE", "GET", "HEAD", "OPTIONS", "PATCH", "POST", "PUT"} {
+		require.Equal(t, m, routes[i].Method)
+		require.Equal(t, "/any", routes[i].Pattern)
+	}
+	require.Equal(t, &RouteInfo{
+		Method:      http.MethodGet,
+		Pattern:     "/api/users/{id:int}",
+		Params:      []string{"id"},
+		Name:        "user",
+		Handler:     "github.com/go-the-way/anoweb.routesUsersHandler",
+		Group:       "/api",
+		Middlewares: []string{"middleware.header", "anoweb._middleware", "anoweb._authMiddleware"},
+		Site:        fmt.Sprintf("%s:%d", file, line+1),
+	}, routes[7])
+	require.Equal(t, "/posts/{page?}", routes[8].Pattern)
+	require.Equal(t, []string{"page"}, routes[8].Params)
+	require.Equal(t, "", routes[8].Group)
+	require.Equal(t, []string{"middleware.header", "anoweb._middleware"}, routes[8].Middlewares)
+
+	var buf bytes.Buffer
+	require.Nil(t, a.writeRoutes(&buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 10)
+	require.Equal(t, []string{"METHOD", "PATTERN", "NAME", "HANDLER"}, strings.Fields(lines[0]))
+	require.Equal(t, []string{"GET", "/api/users/{id:int}", "user", "github.com/go-the-way/anoweb.routesUsersHandler"}, strings.Fields(lines[8]))
+}
+
+func TestAppDebugRoutes(t *testing.T) {
+	a := New().Get("/", routesUsersHandler).DebugRoutes("/debug/routes")
+	rec := httptest.NewRecorder()
+	a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/routes", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var routes []*RouteInfo
+	require.Nil(t, json.Unmarshal(rec.Body.Bytes(), &routes))
+	require.Len(t, routes, 2)
+	require.Equal(t, "/", routes[0].Pattern)
+	require.Equal(t, "/debug/routes", routes[1].Pattern)
+	require.Contains(t, routes[1].Handler, "DebugRoutes")
+}
@@ -28,11 +28,12 @@ import (
 type provider struct {
 	mu       *sync.Mutex
 	sessions map[string]se.Session
+	stopped  bool
 }
 
 // Provider return session provider
 func Provider() *provider {
-	return &provider{&sync.Mutex{}, map[string]se.Session{}}
+	return &provider{&sync.Mutex{}, map[string]se.Session{}, false}
 }
 
 // CookieName return cookie name
@@ -124,10 +125,23 @@ func (p *provider) Refresh(session se.Session, config *se.Config, listener *se.L
 
 // Clean session
 func (p *provider) Clean(_ *se.Config, listener *se.Listener) {
+	p.mu.Lock()
+	stopped := p.stopped
+	p.mu.Unlock()
+	if stopped {
+		return
+	}
 	p.cleanSession(listener)
 	time.AfterFunc(time.Second, func() { p.Clean(nil, listener) })
 }
 
+// Stop the Clean loop
+func (p *provider) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stopped = true
+}
+
 func (p *provider) cleanSession(listener *se.Listener) {
 	for _, currentSession := range p.GetAll() {
 		nu := time.Now().Unix()
@@ -106,3 +106,11 @@ func TestProviderCleanSession(t *testing.T) {
 	require.Nil(t, p.Get(s3.Id()))
 	require.Equal(t, 6, cc)
 }
+
+func TestProviderStop(t *testing.T) {
+	p := Provider()
+	p.Stop()
+	s1 := p.New(&se.Config{}, nil)
+	p.Clean(nil, nil)
+	require.NotNil(t, p.Get(s1.Id()))
+}
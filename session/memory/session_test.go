@@ -12,456 +12,162 @@
 package memory
 
 import (
-	"errors"
-	"fmt"
 	"net/http"
-	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/go-the-way/anoweb"
+	"github.com/go-the-way/anoweb/anotest"
 	"github.com/go-the-way/anoweb/context"
 	"github.com/go-the-way/anoweb/middleware"
 	s "github.com/go-the-way/anoweb/session"
-)
-
-var _port = int32(9000)
 
-func nextPort() int {
-	return int(atomic.AddInt32(&_port, 1))
-}
+	"github.com/stretchr/testify/require"
+)
 
-func currSessionId(resp *http.Response) string {
-	sessionId := ""
-	cookies := resp.Cookies()
-	for _, c := range cookies {
-		if c.Name == "GOSESSID" {
-			sessionId = c.Value
-			break
-		}
-	}
-	return sessionId
+func testSessionClient(t *testing.T, handler func(ctx *context.Context), listener *s.Listener) *anotest.Client {
+	app := anoweb.New()
+	app.Get("/", handler).UseSession(Provider(), &s.Config{Valid: time.Minute}, listener)
+	return anotest.New(t, app)
 }
 
 func TestSession(t *testing.T) {
-	sessionCh := make(chan string, 1)
-	port := nextPort()
-	go func() {
-		app := anoweb.New()
-		app.Config.Server.Port = port
-		app.Get("/", func(ctx *context.Context) {
-			sessionCh <- middleware.GetSession(ctx).Id()
-		}).UseSession(Provider(), &s.Config{Valid: time.Minute}, nil).Run()
-	}()
-	errCh := make(chan error, 1)
-	okCh := make(chan struct{}, 1)
-	time.AfterFunc(time.Millisecond*500, func() {
-		resp, err := http.Get(fmt.Sprintf("http://localhost:%d", port))
-		if err != nil {
-			errCh <- err
-		}
-
-		sessionId := ""
-		cookies := resp.Cookies()
-		for _, c := range cookies {
-			if c.Name == "GOSESSID" {
-				sessionId = c.Value
-				break
-			}
-		}
-
-		select {
-		case <-time.After(time.Millisecond * 200):
-			errCh <- errors.New("get session id timeout")
-		case currSession := <-sessionCh:
-			if sessionId == currSession {
-				okCh <- struct{}{}
-			} else {
-				errCh <- errors.New("session id not equal")
-			}
-		}
-
-	})
-	select {
-	case <-time.After(time.Second):
-		t.Error("test fail: timeout")
-	case err := <-errCh:
-		t.Errorf("test err: %v", err)
-	case <-okCh:
-		t.Log("test ok")
-	}
-
+	sessionId := ""
+	c := testSessionClient(t, func(ctx *context.Context) {
+		sessionId = middleware.GetSession(ctx).Id()
+	}, nil)
+	c.Get("/").Do().AssertStatus(http.StatusOK).AssertCookie("GOSESSID", sessionId)
+	require.NotEmpty(t, sessionId)
 }
 
 func TestSessionRenew(t *testing.T) {
-	port := nextPort()
-	invalidatedCh := make(chan bool, 1)
-	go func() {
-		app := anoweb.New()
-		app.Config.Server.Port = port
-		app.Get("/renew", func(ctx *context.Context) {
-			currSession := middleware.GetSession(ctx)
-			invalidatedCh <- currSession.Invalidated()
-		}).UseSession(Provider(), &s.Config{Valid: time.Minute}, nil).Run()
-	}()
-	errCh := make(chan error, 1)
-	okCh := make(chan struct{}, 1)
-	time.AfterFunc(time.Millisecond*500, func() {
-		resp, err := http.Get(fmt.Sprintf("http://localhost:%d", port))
-		if err != nil {
-			errCh <- err
-		}
-
-		sessionId := currSessionId(resp)
-		t.Logf("get session: %s", sessionId)
-
-		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost:%d/renew", port), nil)
-		if err != nil {
-			errCh <- err
-		}
-		req.AddCookie(&http.Cookie{Name: "GOSESSID", Value: sessionId})
-		resp, err = http.DefaultClient.Do(req)
-		if err != nil {
-			errCh <- err
-		}
-
-		select {
-		case <-time.After(time.Millisecond * 200):
-			errCh <- errors.New("get session invalidated timeout")
-		case invalidated := <-invalidatedCh:
-			if !invalidated {
-				okCh <- struct{}{}
-			} else {
-				errCh <- errors.New("session must be not invalidated")
-			}
-		}
-	})
-	select {
-	case <-time.After(time.Second):
-		t.Error("test fail: timeout")
-	case err := <-errCh:
-		t.Errorf("test err: %v", err)
-	case <-okCh:
-		t.Log("test ok")
-	}
+	ids := make([]string, 0)
+	invalidated := true
+	c := testSessionClient(t, func(ctx *context.Context) {
+		currSession := middleware.GetSession(ctx)
+		ids = append(ids, currSession.Id())
+		invalidated = currSession.Invalidated()
+	}, nil)
+	c.Get("/").Do()
+	c.Get("/").Do()
+	require.Len(t, ids, 2)
+	require.Equal(t, ids[0], ids[1])
+	require.False(t, invalidated)
 }
 
 func TestSessionInvalidated(t *testing.T) {
-	port := nextPort()
-	invalidatedCh := make(chan bool, 1)
-	go func() {
-		app := anoweb.New()
-		app.Config.Server.Port = port
-		app.Get("/", func(ctx *context.Context) {
-			currSession := middleware.GetSession(ctx)
-			invalidatedCh <- currSession.Invalidated()
-		}).UseSession(Provider(), &s.Config{Valid: time.Minute}, nil).Run()
-	}()
-	errCh := make(chan error, 1)
-	okCh := make(chan struct{}, 1)
-	time.AfterFunc(time.Millisecond*500, func() {
-		_, err := http.Get(fmt.Sprintf("http://localhost:%d", port))
-		if err != nil {
-			errCh <- err
-		}
-		select {
-		case <-invalidatedCh:
-			okCh <- struct{}{}
-		}
-	})
-	select {
-	case <-time.After(time.Second):
-		t.Error("test fail: timeout")
-	case err := <-errCh:
-		t.Errorf("test err: %v", err)
-	case <-okCh:
-		t.Log("test ok")
-	}
-
+	invalidated := true
+	c := testSessionClient(t, func(ctx *context.Context) {
+		invalidated = middleware.GetSession(ctx).Invalidated()
+	}, nil)
+	c.Get("/").Do().AssertStatus(http.StatusOK)
+	require.False(t, invalidated)
 }
 
 func TestSessionInvalidate(t *testing.T) {
-	port := nextPort()
-	invalidatedCh := make(chan bool, 1)
-	go func() {
-		app := anoweb.New()
-		app.Config.Server.Port = port
-		app.Get("/", func(ctx *context.Context) {
-			currSession := middleware.GetSession(ctx)
-			currSession.Invalidate()
-			invalidatedCh <- currSession.Invalidated()
-		}).UseSession(Provider(), &s.Config{Valid: time.Minute}, nil).Run()
-	}()
-	errCh := make(chan error, 1)
-	okCh := make(chan struct{}, 1)
-	time.AfterFunc(time.Millisecond*500, func() {
-		_, err := http.Get(fmt.Sprintf("http://localhost:%d", port))
-		if err != nil {
-			errCh <- err
-		}
-
-		select {
-		case invalidated := <-invalidatedCh:
-			if invalidated {
-				okCh <- struct{}{}
-			} else {
-				errCh <- errors.New("not expected")
-			}
-		}
-	})
-	select {
-	case <-time.After(time.Second):
-		t.Error("test fail: timeout")
-	case err := <-errCh:
-		t.Errorf("test err: %v", err)
-	case <-okCh:
-		t.Log("test ok")
-	}
+	invalidated := false
+	c := testSessionClient(t, func(ctx *context.Context) {
+		currSession := middleware.GetSession(ctx)
+		currSession.Invalidate()
+		invalidated = currSession.Invalidated()
+	}, nil)
+	c.Get("/").Do()
+	require.True(t, invalidated)
 }
 
 func TestSessionGet(t *testing.T) {
-	port := nextPort()
-	dataCh := make(chan string, 1)
-	go func() {
-		app := anoweb.New()
-		app.Config.Server.Port = port
-		app.Get("/", func(ctx *context.Context) {
-			currSession := middleware.GetSession(ctx)
+	var apple interface{}
+	c := testSessionClient(t, func(ctx *context.Context) {
+		currSession := middleware.GetSession(ctx)
+		if ctx.Param("set") != "" {
 			currSession.Set("apple", "100")
-			dataCh <- currSession.Get("apple").(string)
-		}).UseSession(Provider(), &s.Config{Valid: time.Minute}, nil).Run()
-	}()
-	errCh := make(chan error, 1)
-	okCh := make(chan struct{}, 1)
-	time.AfterFunc(time.Millisecond*500, func() {
-		_, err := http.Get(fmt.Sprintf("http://localhost:%d", port))
-		if err != nil {
-			errCh <- err
-		}
-
-		select {
-		case apple := <-dataCh:
-			if apple == "100" {
-				okCh <- struct{}{}
-			} else {
-				errCh <- errors.New("not expected")
-			}
 		}
-	})
-	select {
-	case <-time.After(time.Second):
-		t.Error("test fail: timeout")
-	case err := <-errCh:
-		t.Errorf("test err: %v", err)
-	case <-okCh:
-		t.Log("test ok")
-	}
+		apple = currSession.Get("apple")
+	}, nil)
+	c.Get("/").Query("set", "1").Do()
+	require.Equal(t, "100", apple)
+	apple = nil
+	c.Get("/").Do()
+	require.Equal(t, "100", apple)
 }
 
 func TestSessionGetAll(t *testing.T) {
-	port := nextPort()
-	dataCh := make(chan map[string]interface{}, 1)
-	go func() {
-		app := anoweb.New()
-		app.Config.Server.Port = port
-		app.Get("/", func(ctx *context.Context) {
-			currSession := middleware.GetSession(ctx)
-			dataCh <- currSession.GetAll()
-		}).UseSession(Provider(), &s.Config{Valid: time.Minute}, &s.Listener{Created: func(session s.Session) {
-			session.SetAll(map[string]interface{}{
-				"apple":  "100",
-				"banana": "200",
-			}, false)
-		}}).Run()
-	}()
-	errCh := make(chan error, 1)
-	okCh := make(chan struct{}, 1)
-	time.AfterFunc(time.Millisecond*500, func() {
-		_, err := http.Get(fmt.Sprintf("http://localhost:%d", port))
-		if err != nil {
-			errCh <- err
-		}
-
-		select {
-		case data := <-dataCh:
-			if data["apple"].(string) == "100" && data["banana"].(string) == "200" {
-				okCh <- struct{}{}
-			} else {
-				errCh <- errors.New("not expected")
-			}
-		}
-	})
-	select {
-	case <-time.After(time.Second):
-		t.Error("test fail: timeout")
-	case err := <-errCh:
-		t.Errorf("test err: %v", err)
-	case <-okCh:
-		t.Log("test ok")
-	}
+	var data map[string]interface{}
+	created := make(chan struct{})
+	c := testSessionClient(t, func(ctx *context.Context) {
+		data = middleware.GetSession(ctx).GetAll()
+	}, &s.Listener{Created: func(session s.Session) {
+		session.SetAll(map[string]interface{}{
+			"apple":  "100",
+			"banana": "200",
+		}, false)
+		close(created)
+	}})
+	// Created is called asynchronously, the second request reuses the session by the cookie jar
+	c.Get("/").Do()
+	<-created
+	c.Get("/").Do()
+	require.Equal(t, map[string]interface{}{"apple": "100", "banana": "200"}, data)
 }
 
 func TestSessionSet(t *testing.T) {
-	port := nextPort()
-	dataCh := make(chan string, 1)
-	go func() {
-		app := anoweb.New()
-		app.Config.Server.Port = port
-		app.Get("/", func(ctx *context.Context) {
-			currSession := middleware.GetSession(ctx)
-			currSession.Set("apple", "100")
-			dataCh <- currSession.Get("apple").(string)
-		}).UseSession(Provider(), &s.Config{Valid: time.Minute}, nil).Run()
-	}()
-	errCh := make(chan error, 1)
-	okCh := make(chan struct{}, 1)
-	time.AfterFunc(time.Millisecond*500, func() {
-		_, err := http.Get(fmt.Sprintf("http://localhost:%d", port))
-		if err != nil {
-			errCh <- err
-		}
-
-		select {
-		case apple := <-dataCh:
-			if apple == "100" {
-				okCh <- struct{}{}
-			} else {
-				errCh <- errors.New("not expected")
-			}
-		}
-	})
-	select {
-	case <-time.After(time.Second):
-		t.Error("test fail: timeout")
-	case err := <-errCh:
-		t.Errorf("test err: %v", err)
-	case <-okCh:
-		t.Log("test ok")
-	}
+	var apple interface{}
+	c := testSessionClient(t, func(ctx *context.Context) {
+		currSession := middleware.GetSession(ctx)
+		currSession.Set("apple", "100")
+		apple = currSession.Get("apple")
+	}, nil)
+	c.Get("/").Do()
+	require.Equal(t, "100", apple)
 }
 
 func TestSessionSetAll(t *testing.T) {
-	port := nextPort()
-	dataCh := make(chan map[string]interface{}, 1)
-	go func() {
-		app := anoweb.New()
-		app.Config.Server.Port = port
-		app.Get("/", func(ctx *context.Context) {
-			currSession := middleware.GetSession(ctx)
-			currSession.SetAll(nil, false)
-			currSession.Set("apple", "100")
-			currSession.SetAll(map[string]interface{}{
-				"apple":  "100",
-				"banana": "200",
-			}, true)
-			dataCh <- currSession.GetAll()
-		}).UseSession(Provider(), &s.Config{Valid: time.Minute}, nil).Run()
-	}()
-	errCh := make(chan error, 1)
-	okCh := make(chan struct{}, 1)
-	time.AfterFunc(time.Millisecond*500, func() {
-		_, err := http.Get(fmt.Sprintf("http://localhost:%d", port))
-		if err != nil {
-			errCh <- err
-		}
-
-		select {
-		case data := <-dataCh:
-			if data["apple"].(string) == "100" && data["banana"].(string) == "200" {
-				okCh <- struct{}{}
-			} else {
-				errCh <- errors.New("not expected")
-			}
-		}
-	})
-	select {
-	case <-time.After(time.Second):
-		t.Error("test fail: timeout")
-	case err := <-errCh:
-		t.Errorf("test err: %v", err)
-	case <-okCh:
-		t.Log("test ok")
-	}
+	var data map[string]interface{}
+	c := testSessionClient(t, func(ctx *context.Context) {
+		currSession := middleware.GetSession(ctx)
+		currSession.SetAll(nil, false)
+		currSession.Set("apple", "100")
+		currSession.SetAll(map[string]interface{}{
+			"apple":  "100",
+			"banana": "200",
+		}, true)
+		data = currSession.GetAll()
+	}, nil)
+	c.Get("/").Do()
+	require.Equal(t, "100", data["apple"])
+	require.Equal(t, "200", data["banana"])
 }
 
 func TestSessionDel(t *testing.T) {
-	port := nextPort()
-	dataCh := make(chan interface{}, 1)
-	go func() {
-		app := anoweb.New()
-		app.Config.Server.Port = port
-		app.Get("/", func(ctx *context.Context) {
-			currSession := middleware.GetSession(ctx)
-			currSession.Del("apple")
-			dataCh <- currSession.Get("apple")
-		}).UseSession(Provider(), &s.Config{Valid: time.Minute}, &s.Listener{Created: func(session s.Session) {
-			session.Set("apple", "100")
-		}}).Run()
-	}()
-	errCh := make(chan error, 1)
-	okCh := make(chan struct{}, 1)
-	time.AfterFunc(time.Millisecond*500, func() {
-		_, err := http.Get(fmt.Sprintf("http://localhost:%d", port))
-		if err != nil {
-			errCh <- err
-		}
-
-		select {
-		case data := <-dataCh:
-			if data == nil {
-				okCh <- struct{}{}
-			} else {
-				errCh <- errors.New("not expected")
-			}
-		}
-	})
-	select {
-	case <-time.After(time.Second):
-		t.Error("test fail: timeout")
-	case err := <-errCh:
-		t.Errorf("test err: %v", err)
-	case <-okCh:
-		t.Log("test ok")
-	}
+	var apple interface{} = "none"
+	created := make(chan struct{})
+	c := testSessionClient(t, func(ctx *context.Context) {
+		currSession := middleware.GetSession(ctx)
+		currSession.Del("apple")
+		apple = currSession.Get("apple")
+	}, &s.Listener{Created: func(session s.Session) {
+		session.Set("apple", "100")
+		close(created)
+	}})
+	c.Get("/").Do()
+	<-created
+	c.Get("/").Do()
+	require.Nil(t, apple)
 }
 
 func TestSessionClear(t *testing.T) {
-	port := nextPort()
-	dataCh := make(chan interface{}, 1)
-	go func() {
-		app := anoweb.New()
-		app.Config.Server.Port = port
-		app.Get("/", func(ctx *context.Context) {
-			currSession := middleware.GetSession(ctx)
-			currSession.Clear()
-			dataCh <- currSession.Get("apple")
-		}).UseSession(Provider(), &s.Config{Valid: time.Minute}, &s.Listener{Created: func(session s.Session) {
-			session.Set("apple", "100")
-		}}).Run()
-	}()
-	errCh := make(chan error, 1)
-	okCh := make(chan struct{}, 1)
-	time.AfterFunc(time.Millisecond*500, func() {
-		_, err := http.Get(fmt.Sprintf("http://localhost:%d", port))
-		if err != nil {
-			errCh <- err
-		}
-
-		select {
-		case data := <-dataCh:
-			if data == nil {
-				okCh <- struct{}{}
-			} else {
-				errCh <- errors.New("not expected")
-			}
-		}
-	})
-	select {
-	case <-time.After(time.Second):
-		t.Error("test fail: timeout")
-	case err := <-errCh:
-		t.Errorf("test err: %v", err)
-	case <-okCh:
-		t.Log("test ok")
-	}
+	var apple interface{} = "none"
+	created := make(chan struct{})
+	c := testSessionClient(t, func(ctx *context.Context) {
+		currSession := middleware.GetSession(ctx)
+		currSession.Clear()
+		apple = currSession.Get("apple")
+	}, &s.Listener{Created: func(session s.Session) {
+		session.Set("apple", "100")
+		close(created)
+	}})
+	c.Get("/").Do()
+	<-created
+	c.Get("/").Do()
+	require.Nil(t, apple)
 }
@@ -36,3 +36,9 @@ type Provider interface {
 	// Clean session
 	Clean(config *Config, listener *Listener)
 }
+
+// Stopper interface, implemented by a Provider whose Clean loop can be stopped
+type Stopper interface {
+	// Stop the Clean loop
+	Stop()
+}
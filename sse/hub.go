@@ -0,0 +1,243 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sse provides a Hub publishing Server-Sent Events to the subscribers of topics.
+package sse
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-the-way/anoweb/context"
+)
+
+type entry struct {
+	seq   uint64
+	event *context.Event
+}
+
+// Hub publishes events to the subscribers of topics, it's safe for concurrent use.
+//
+// Each topic keeps the last History events, so reconnecting clients resume after their Last-Event-ID.
+type Hub struct {
+	// History events kept per topic for resumption
+	History int
+	// Buffer events buffered per subscriber, a subscriber falling behind is dropped
+	Buffer int
+
+	mu      sync.Mutex
+	seq     uint64
+	subs    map[string]map[*Subscriber]struct{}
+	history map[string][]*entry
+	closed  bool
+}
+
+// Subscriber subscribes topics of a Hub
+type Subscriber struct {
+	// C receives the published events, closed when unsubscribed or dropped
+	C <-chan *context.Event
+
+	c      chan *context.Event
+	hub    *Hub
+	topics []string
+	closed bool
+}
+
+// NewHub return new Hub
+func NewHub() *Hub {
+	return &Hub{
+		History: 100,
+		Buffer:  16,
+		subs:    make(map[string]map[*Subscriber]struct{}, 0),
+		history: make(map[string][]*entry, 0),
+	}
+}
+
+// Subscribe return new Subscriber of the topics
+func (h *Hub) Subscribe(topics ...string) *Subscriber {
+	s, _ := h.SubscribeFrom("", topics...)
+	return s
+}
+
+// SubscribeFrom return new Subscriber of the topics and the kept events published after lastEventID,
+// no event is replayed when lastEventID is empty or no longer kept. The repeated topics are subscribed once.
+func (h *Hub) SubscribeFrom(lastEventID string, topics ...string) (*Subscriber, []*context.Event) {
+	topics = uniqueTopics(topics)
+	c := make(chan *context.Event, h.Buffer)
+	s := &Subscriber{C: c, c: c, hub: h, topics: topics}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		s.closed = true
+		close(c)
+		return s, nil
+	}
+	for _, topic := range topics {
+		if h.subs[topic] == nil {
+			h.subs[topic] = make(map[*Subscriber]struct{}, 0)
+		}
+		h.subs[topic][s] = struct{}{}
+	}
+	return s, h.replay(lastEventID, topics)
+}
+
+func uniqueTopics(topics []string) []string {
+	unique := make([]string, 0, len(topics))
+	seen := make(map[string]bool, len(topics))
+	for _, topic := range topics {
+		if !seen[topic] {
+			seen[topic] = true
+			unique = append(unique, topic)
+		}
+	}
+	return unique
+}
+
+func (h *Hub) replay(lastEventID string, topics []string) []*context.Event {
+	if lastEventID == "" {
+		return nil
+	}
+	var last uint64
+	for _, topic := range topics {
+		for _, e := range h.history[topic] {
+			if e.event.ID == lastEventID {
+				last = e.seq
+			}
+		}
+	}
+	if last == 0 {
+		return nil
+	}
+	entries := make([]*entry, 0)
+	for _, topic := range topics {
+		for _, e := range h.history[topic] {
+			if e.seq > last {
+				entries = append(entries, e)
+			}
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].seq < entries[j].seq })
+	events := make([]*context.Event, len(entries))
+	for i, e := range entries {
+		events[i] = e.event
+	}
+	return events
+}
+
+// Publish publishes the copy of the event to the subscribers of the topic,
+// an empty ID of the copy is assigned from the Hub sequence, so the event can be published again
+func (h *Hub) Publish(topic string, event *context.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	h.seq++
+	copied := *event
+	event = &copied
+	if event.ID == "" {
+		event.ID = strconv.FormatUint(h.seq, 10)
+	}
+	if h.History > 0 {
+		hs := append(h.history[topic], &entry{h.seq, event})
+		if len(hs) > h.History {
+			hs = hs[len(hs)-h.History:]
+		}
+		h.history[topic] = hs
+	}
+	for s := range h.subs[topic] {
+		select {
+		case s.c <- event:
+		default:
+			h.unsubscribe(s)
+		}
+	}
+}
+
+// Subscribers return the number of subscribers of the topic
+func (h *Hub) Subscribers(topic string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs[topic])
+}
+
+// Close unsubscribes all subscribers, later publishing is ignored
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for _, subs := range h.subs {
+		for s := range subs {
+			h.unsubscribe(s)
+		}
+	}
+}
+
+// Close unsubscribes the Subscriber
+func (s *Subscriber) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.unsubscribe(s)
+}
+
+func (h *Hub) unsubscribe(s *Subscriber) {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	for _, topic := range s.topics {
+		delete(h.subs[topic], s)
+		if len(h.subs[topic]) == 0 {
+			delete(h.subs, topic)
+		}
+	}
+	close(s.c)
+}
+
+// Serve streams the events of the topics to the client until it disconnects, the Subscriber is dropped or the Hub closed.
+//
+// Events published after the Last-Event-ID of the request are replayed first,
+// a heartbeat comment is sent every heartbeat when it's positive.
+func (h *Hub) Serve(ctx *context.Context, heartbeat time.Duration, topics ...string) error {
+	w := ctx.SSE()
+	s, replay := h.SubscribeFrom(w.LastEventID(), topics...)
+	defer s.Close()
+	for _, e := range replay {
+		if err := w.Send(e); err != nil {
+			return err
+		}
+	}
+	var tick <-chan time.Time
+	if heartbeat > 0 {
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-w.Done():
+			return nil
+		case e, ok := <-s.C:
+			if !ok {
+				return nil
+			}
+			if err := w.Send(e); err != nil {
+				return err
+			}
+		case <-tick:
+			if err := w.Heartbeat(); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sse
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-the-way/anoweb"
+	"github.com/go-the-way/anoweb/context"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHub(t *testing.T) {
+	h := NewHub()
+	s1 := h.Subscribe("a")
+	s2 := h.Subscribe("a", "b")
+	require.Equal(t, 2, h.Subscribers("a"))
+	require.Equal(t, 1, h.Subscribers("b"))
+	h.Publish("a", &context.Event{Data: "1"})
+	h.Publish("b", &context.Event{ID: "custom", Data: "2"})
+	require.Equal(t, "1", (<-s1.C).ID)
+	require.Equal(t, "1", (<-s2.C).Data)
+	require.Equal(t, "custom", (<-s2.C).ID)
+	s1.Close()
+	s1.Close()
+	_, ok := <-s1.C
+	require.False(t, ok)
+	require.Equal(t, 1, h.Subscribers("a"))
+	h.Close()
+	_, ok = <-s2.C
+	require.False(t, ok)
+	require.Equal(t, 0, h.Subscribers("a"))
+	h.Publish("a", &context.Event{Data: "ignored"})
+	_, ok = <-h.Subscribe("a").C
+	require.False(t, ok)
+}
+
+func TestHubDropSlow(t *testing.T) {
+	h := NewHub()
+	h.Buffer = 1
+	s := h.Subscribe("a")
+	h.Publish("a", &context.Event{Data: "1"})
+	h.Publish("a", &context.Event{Data: "2"})
+	require.Equal(t, "1", (<-s.C).Data)
+	_, ok := <-s.C
+	require.False(t, ok)
+	require.Equal(t, 0, h.Subscribers("a"))
+}
+
+func TestHubReplay(t *testing.T) {
+	h := NewHub()
+	h.History = 3
+	for i := 0; i < 5; i++ {
+		h.Publish("a", &context.Event{Data: "a"})
+		h.Publish("b", &context.Event{Data: "b"})
+	}
+	ids := func(events []*context.Event) []string {
+		s := make([]string, 0)
+		for _, e := range events {
+			s = append(s, e.ID)
+		}
+		return s
+	}
+	_, events := h.SubscribeFrom("7", "a", "b")
+	require.Equal(t, []string{"8", "9", "10"}, ids(events))
+	_, events = h.SubscribeFrom("7", "a")
+	require.Equal(t, []string{"9"}, ids(events))
+	_, events = h.SubscribeFrom("1", "a")
+	require.Empty(t, events)
+	_, events = h.SubscribeFrom("", "a")
+	require.Empty(t, events)
+	s, events := h.SubscribeFrom("7", "a", "b", "a")
+	require.Equal(t, []string{"8", "9", "10"}, ids(events))
+	require.Equal(t, []string{"a", "b"}, s.topics)
+	subscribers := h.Subscribers("a")
+	s.Close()
+	require.Equal(t, subscribers-1, h.Subscribers("a"))
+}
+
+func TestHubPublishCopy(t *testing.T) {
+	h := NewHub()
+	a, b := h.Subscribe("a"), h.Subscribe("b")
+	event := &context.Event{Data: "x"}
+	h.Publish("a", event)
+	h.Publish("b", event)
+	require.Empty(t, event.ID)
+	require.Equal(t, "1", (<-a.C).ID)
+	require.Equal(t, "2", (<-b.C).ID)
+	_, events := h.SubscribeFrom("1", "a", "b")
+	require.Len(t, events, 1)
+	require.Equal(t, "2", events[0].ID)
+}
+
+func TestHubServe(t *testing.T) {
+	h := NewHub()
+	h.Publish("news", &context.Event{Data: "old"})
+	h.Publish("news", &context.Event{Data: "missed"})
+	a := anoweb.New()
+	a.Get("/events", func(ctx *context.Context) {
+		_ = h.Serve(ctx, 20*time.Millisecond, "news")
+	})
+	server := httptest.NewServer(a.Handler())
+	defer server.Close()
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/events", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	require.Equal(t, "text/event-stream;charset=utf-8", resp.Header.Get("Content-Type"))
+	r := bufio.NewReader(resp.Body)
+	readEvent := func() string {
+		lines := make([]string, 0)
+		for {
+			line, err := r.ReadString('\n')
+			require.Nil(t, err)
+			if line == "\n" {
+				return strings.Join(lines, "")
+			}
+			lines = append(lines, line)
+		}
+	}
+	require.Equal(t, "id: 2\ndata: missed\n", readEvent())
+	require.Eventually(t, func() bool { return h.Subscribers("news") == 1 }, time.Second, time.Millisecond)
+	h.Publish("news", &context.Event{Event: "update", Data: "new"})
+	require.Equal(t, "id: 3\nevent: update\ndata: new\n", readEvent())
+	require.Equal(t, ":\n", readEvent())
+	h.Close()
+}
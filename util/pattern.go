@@ -14,6 +14,7 @@ package util
 import (
 	"regexp"
 	"strings"
+	"unicode/utf8"
 )
 
 // TrimSpecialChars string
@@ -24,6 +25,9 @@ func TrimSpecialChars(str string) string {
 
 // ReBuildPath re-build pattern
 func ReBuildPath(pattern string) string {
+	if rebuilt(pattern) {
+		return pattern
+	}
 	ps := strings.Split(pattern, "/")
 	newPs := make([]string, 0)
 	// append head
@@ -36,3 +40,20 @@ func ReBuildPath(pattern string) string {
 	}
 	return strings.Join(newPs, "/")
 }
+
+// rebuilt reports whether the pattern is already re-built, so it can be returned as is
+func rebuilt(pattern string) bool {
+	if pattern == "" {
+		return true
+	}
+	if pattern[0] != '/' || pattern[len(pattern)-1] == '/' {
+		return false
+	}
+	for i := 1; i < len(pattern); i++ {
+		c := pattern[i]
+		if c >= utf8.RuneSelf || c == ' ' || c >= '\t' && c <= '\r' || c == '/' && pattern[i-1] == '/' {
+			return false
+		}
+	}
+	return true
+}
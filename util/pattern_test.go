@@ -31,12 +31,19 @@ func TestReBuildPath(t *testing.T) {
 		{"hello,{}[]-=*/-12vb", "/hello,{}[]-=*/-12vb"},
 		{"/hello/world", "/hello/world"},
 		{"/hello_world/abc/xyz", "/hello_world/abc/xyz"},
+		{"", ""},
+		{"/", ""},
+		{"//hello//world/", "/hello/world"},
+		{"/hello/ world /", "/hello/world"},
+		{"/hello/wörld", "/hello/wörld"},
 	}
 
 	for _, c := range cases {
 		require.Equal(t, c.expect, ReBuildPath(c.pattern))
 	}
 
+	require.Zero(t, testing.AllocsPerRun(10, func() { ReBuildPath("/hello_world/abc/xyz") }))
+
 }
 
 func TestTrimSpecialChars(t *testing.T) {
@@ -0,0 +1,422 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package websocket implements the server side of the WebSocket protocol (RFC 6455).
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Message types, the values are the frame opcodes
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+)
+
+const (
+	continuationFrame = 0
+
+	finBit  = 0x80
+	rsvBits = 0x70
+	maskBit = 0x80
+
+	maxControlPayload = 125
+)
+
+// Close codes (RFC 6455 section 7.4.1)
+const (
+	CloseNormalClosure           = 1000
+	CloseGoingAway               = 1001
+	CloseProtocolError           = 1002
+	CloseUnsupportedData         = 1003
+	CloseNoStatusReceived        = 1005
+	CloseAbnormalClosure         = 1006
+	CloseInvalidFramePayloadData = 1007
+	ClosePolicyViolation         = 1008
+	CloseMessageTooBig           = 1009
+	CloseMandatoryExtension      = 1010
+	CloseInternalServerErr       = 1011
+)
+
+var (
+	// ErrCloseSent writing after the close frame is sent
+	ErrCloseSent = errors.New("websocket: close sent")
+	// ErrInvalidControl the control frame is too large or fragmented
+	ErrInvalidControl = errors.New("websocket: invalid control frame")
+)
+
+// CloseError the close frame received from the peer
+type CloseError struct {
+	Code int
+	Text string
+}
+
+// Error implements
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: close %d %s", e.Code, e.Text)
+}
+
+// ProtocolError the peer violated the protocol, the connection is closed with Code
+type ProtocolError struct {
+	Code int
+	Msg  string
+}
+
+// Error implements
+func (e *ProtocolError) Error() string {
+	return "websocket: " + e.Msg
+}
+
+// Conn a WebSocket connection, one goroutine may read while another writes
+type Conn struct {
+	conn        net.Conn
+	br          *bufio.Reader
+	server      bool
+	subprotocol string
+
+	readLimit   int64
+	readErr     error
+	pingHandler func(data []byte) error
+	pongHandler func(data []byte) error
+
+	wmu       sync.Mutex
+	closeSent bool
+
+	dmu           sync.Mutex
+	writeDeadline time.Time
+}
+
+func newConn(conn net.Conn, br *bufio.Reader, server bool, readLimit int64) *Conn {
+	if br == nil {
+		br = bufio.NewReader(conn)
+	}
+	c := &Conn{conn: conn, br: br, server: server, readLimit: readLimit}
+	c.pingHandler = func(data []byte) error {
+		err := c.WriteControl(PongMessage, data, time.Now().Add(time.Second))
+		if err == ErrCloseSent {
+			return nil
+		}
+		return err
+	}
+	c.pongHandler = func([]byte) error { return nil }
+	return c
+}
+
+// Subprotocol return the negotiated subprotocol
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// LocalAddr return the local network address
+func (c *Conn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+// RemoteAddr return the remote network address
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// SetReadLimit sets the maximum message size, a larger message closes the connection with CloseMessageTooBig
+func (c *Conn) SetReadLimit(limit int64) {
+	c.readLimit = limit
+}
+
+// SetReadDeadline sets the read deadline of the underlying connection
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the write deadline of the underlying connection,
+// which is restored after the control frames written with their own deadlines
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	c.dmu.Lock()
+	defer c.dmu.Unlock()
+	c.writeDeadline = t
+	return c.conn.SetWriteDeadline(t)
+}
+
+// SetPingHandler sets the handler of received pings, the default replies a pong
+func (c *Conn) SetPingHandler(h func(data []byte) error) {
+	c.pingHandler = h
+}
+
+// SetPongHandler sets the handler of received pongs, e.g. to extend the read deadline
+func (c *Conn) SetPongHandler(h func(data []byte) error) {
+	c.pongHandler = h
+}
+
+// ReadMessage reads the next data message, control frames are handled meanwhile.
+//
+// A close frame from the peer is answered and returned as *CloseError,
+// once an error is returned all later reads return it too.
+func (c *Conn) ReadMessage() (messageType int, data []byte, err error) {
+	if c.readErr != nil {
+		return 0, nil, c.readErr
+	}
+	messageType, data, err = c.readMessage()
+	if err != nil {
+		if pe, ok := err.(*ProtocolError); ok {
+			_ = c.WriteClose(pe.Code, pe.Msg)
+		}
+		c.readErr = err
+	}
+	return
+}
+
+func (c *Conn) readMessage() (int, []byte, error) {
+	messageType := 0
+	data := make([]byte, 0)
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+		switch opcode {
+		case PingMessage:
+			if err = c.pingHandler(payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			if err = c.pongHandler(payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case CloseMessage:
+			return 0, nil, c.handleClose(payload)
+		case TextMessage, BinaryMessage:
+			if messageType != 0 {
+				return 0, nil, &ProtocolError{CloseProtocolError, "data frame inside a fragmented message"}
+			}
+			messageType = opcode
+		case continuationFrame:
+			if messageType == 0 {
+				return 0, nil, &ProtocolError{CloseProtocolError, "continuation frame without a message"}
+			}
+		default:
+			return 0, nil, &ProtocolError{CloseProtocolError, fmt.Sprintf("unknown opcode %d", opcode)}
+		}
+		if c.readLimit > 0 && int64(len(data)+len(payload)) > c.readLimit {
+			return 0, nil, &ProtocolError{CloseMessageTooBig, "read limit exceeded"}
+		}
+		data = append(data, payload...)
+		if fin {
+			if messageType == TextMessage && !utf8.Valid(data) {
+				return 0, nil, &ProtocolError{CloseInvalidFramePayloadData, "invalid utf-8 text"}
+			}
+			return messageType, data, nil
+		}
+	}
+}
+
+func (c *Conn) handleClose(payload []byte) error {
+	code, text := CloseNoStatusReceived, ""
+	switch {
+	case len(payload) == 1:
+		return &ProtocolError{CloseProtocolError, "invalid close payload"}
+	case len(payload) >= 2:
+		code = int(binary.BigEndian.Uint16(payload))
+		text = string(payload[2:])
+		if !validCloseCode(code) {
+			return &ProtocolError{CloseProtocolError, fmt.Sprintf("invalid close code %d", code)}
+		}
+		if !utf8.ValidString(text) {
+			return &ProtocolError{CloseInvalidFramePayloadData, "invalid utf-8 close reason"}
+		}
+	}
+	replyCode := code
+	if replyCode == CloseNoStatusReceived {
+		replyCode = CloseNormalClosure
+	}
+	_ = c.WriteClose(replyCode, "")
+	return &CloseError{code, text}
+}
+
+func validCloseCode(code int) bool {
+	switch {
+	case code >= CloseNormalClosure && code <= CloseUnsupportedData:
+		return true
+	case code >= CloseInvalidFramePayloadData && code <= CloseInternalServerErr:
+		return true
+	default:
+		return code >= 3000 && code <= 4999
+	}
+}
+
+func (c *Conn) readFrame() (fin bool, opcode int, payload []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(c.br, head[:]); err != nil {
+		return
+	}
+	fin = head[0]&finBit != 0
+	opcode = int(head[0] & 0x0f)
+	if head[0]&rsvBits != 0 {
+		err = &ProtocolError{CloseProtocolError, "reserved bits set"}
+		return
+	}
+	masked := head[1]&maskBit != 0
+	if masked != c.server {
+		err = &ProtocolError{CloseProtocolError, "invalid frame masking"}
+		return
+	}
+	length := int64(head[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		if ext[0]&0x80 != 0 {
+			err = &ProtocolError{CloseProtocolError, "invalid payload length"}
+			return
+		}
+		length = int64(binary.BigEndian.Uint64(ext[:]))
+	}
+	if opcode >= CloseMessage && (!fin || length > maxControlPayload) {
+		err = &ProtocolError{CloseProtocolError, "invalid control frame"}
+		return
+	}
+	if opcode < CloseMessage && c.readLimit > 0 && length > c.readLimit {
+		err = &ProtocolError{CloseMessageTooBig, "read limit exceeded"}
+		return
+	}
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+			return
+		}
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return
+	}
+	if masked {
+		maskBytes(mask, payload)
+	}
+	return
+}
+
+func maskBytes(mask [4]byte, b []byte) {
+	for i := range b {
+		b[i] ^= mask[i%4]
+	}
+}
+
+// WriteMessage writes the data message as a single frame
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return fmt.Errorf("websocket: invalid message type %d", messageType)
+	}
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	return c.writeFrame(messageType, data)
+}
+
+// WriteText writes the text message
+func (c *Conn) WriteText(text string) error {
+	return c.WriteMessage(TextMessage, []byte(text))
+}
+
+// WriteControl writes the ping, pong or close frame with the write deadline,
+// the deadline set by SetWriteDeadline is restored afterwards
+func (c *Conn) WriteControl(messageType int, data []byte, deadline time.Time) error {
+	if messageType < CloseMessage || messageType > PongMessage || len(data) > maxControlPayload {
+		return ErrInvalidControl
+	}
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	_ = c.conn.SetWriteDeadline(deadline)
+	defer func() {
+		c.dmu.Lock()
+		defer c.dmu.Unlock()
+		_ = c.conn.SetWriteDeadline(c.writeDeadline)
+	}()
+	return c.writeFrame(messageType, data)
+}
+
+// Ping writes the ping frame
+func (c *Conn) Ping(data []byte) error {
+	return c.WriteControl(PingMessage, data, time.Now().Add(time.Second))
+}
+
+// WriteClose writes the close frame with the code and reason, no frame can be written afterwards
+func (c *Conn) WriteClose(code int, text string) error {
+	payload := make([]byte, 2, 2+len(text))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, text...)
+	if len(payload) > maxControlPayload {
+		payload = payload[:maxControlPayload]
+	}
+	return c.WriteControl(CloseMessage, payload, time.Now().Add(time.Second))
+}
+
+// Close writes the normal close frame when none is sent and closes the underlying connection
+func (c *Conn) Close() error {
+	_ = c.WriteClose(CloseNormalClosure, "")
+	return c.conn.Close()
+}
+
+func (c *Conn) writeFrame(opcode int, data []byte) error {
+	if c.closeSent {
+		return ErrCloseSent
+	}
+	if opcode == CloseMessage {
+		c.closeSent = true
+	}
+	frame := make([]byte, 0, len(data)+14)
+	frame = append(frame, finBit|byte(opcode))
+	var maskFlag byte
+	if !c.server {
+		maskFlag = maskBit
+	}
+	switch n := len(data); {
+	case n < 126:
+		frame = append(frame, maskFlag|byte(n))
+	case n <= 0xffff:
+		frame = append(frame, maskFlag|126, byte(n>>8), byte(n))
+	default:
+		var ext [8]byte
+		binary.BigEndian.PutUint64(ext[:], uint64(n))
+		frame = append(append(frame, maskFlag|127), ext[:]...)
+	}
+	if c.server {
+		frame = append(frame, data...)
+	} else {
+		var mask [4]byte
+		_, _ = rand.Read(mask[:])
+		frame = append(frame, mask[:]...)
+		payload := append([]byte(nil), data...)
+		maskBytes(mask, payload)
+		frame = append(frame, payload...)
+	}
+	_, err := c.conn.Write(frame)
+	return err
+}
@@ -0,0 +1,225 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testPipe return the server and the raw client side of an in-memory connection
+func testPipe(readLimit int64) (*Conn, net.Conn) {
+	s, c := net.Pipe()
+	return newConn(s, nil, true, readLimit), c
+}
+
+func rawFrame(fin bool, opcode int, payload []byte, masked bool) []byte {
+	b0 := byte(opcode)
+	if fin {
+		b0 |= finBit
+	}
+	frame := []byte{b0}
+	var m byte
+	if masked {
+		m = maskBit
+	}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, m|byte(n))
+	case n <= 0xffff:
+		frame = append(frame, m|126, byte(n>>8), byte(n))
+	default:
+		var ext [8]byte
+		binary.BigEndian.PutUint64(ext[:], uint64(n))
+		frame = append(append(frame, m|127), ext[:]...)
+	}
+	if masked {
+		mask := [4]byte{1, 2, 3, 4}
+		frame = append(frame, mask[:]...)
+		p := append([]byte(nil), payload...)
+		maskBytes(mask, p)
+		return append(frame, p...)
+	}
+	return append(frame, payload...)
+}
+
+func closePayload(code int, text string) []byte {
+	p := make([]byte, 2)
+	binary.BigEndian.PutUint16(p, uint16(code))
+	return append(p, text...)
+}
+
+// readServerFrame reads an unmasked frame sent by the server
+func readServerFrame(t *testing.T, br *bufio.Reader) (int, []byte) {
+	client := newConn(nil, br, false, 0)
+	fin, opcode, payload, err := client.readFrame()
+	require.Nil(t, err)
+	require.True(t, fin)
+	return opcode, payload
+}
+
+func TestConnReadMessage(t *testing.T) {
+	server, client := testPipe(0)
+	go func() {
+		_, _ = client.Write(rawFrame(true, TextMessage, []byte("hello"), true))
+		_, _ = client.Write(rawFrame(false, BinaryMessage, []byte{1}, true))
+		_, _ = client.Write(rawFrame(false, continuationFrame, []byte{2}, true))
+		_, _ = client.Write(rawFrame(true, PongMessage, nil, true))
+		_, _ = client.Write(rawFrame(true, continuationFrame, []byte{3}, true))
+		_, _ = client.Write(rawFrame(true, BinaryMessage, make([]byte, 70000), true))
+	}()
+	pongs := 0
+	server.SetPongHandler(func([]byte) error {
+		pongs++
+		return nil
+	})
+	mt, data, err := server.ReadMessage()
+	require.Nil(t, err)
+	require.Equal(t, TextMessage, mt)
+	require.Equal(t, "hello", string(data))
+	mt, data, err = server.ReadMessage()
+	require.Nil(t, err)
+	require.Equal(t, BinaryMessage, mt)
+	require.Equal(t, []byte{1, 2, 3}, data)
+	require.Equal(t, 1, pongs)
+	_, data, err = server.ReadMessage()
+	require.Nil(t, err)
+	require.Len(t, data, 70000)
+}
+
+func TestConnPingAndClose(t *testing.T) {
+	server, client := testPipe(0)
+	br := bufio.NewReader(client)
+	errCh := make(chan error, 1)
+	go func() {
+		_, _, err := server.ReadMessage()
+		errCh <- err
+	}()
+	_, _ = client.Write(rawFrame(true, PingMessage, []byte("p"), true))
+	opcode, payload := readServerFrame(t, br)
+	require.Equal(t, PongMessage, opcode)
+	require.Equal(t, "p", string(payload))
+	_, _ = client.Write(rawFrame(true, CloseMessage, closePayload(CloseGoingAway, "bye"), true))
+	opcode, payload = readServerFrame(t, br)
+	require.Equal(t, CloseMessage, opcode)
+	require.Equal(t, CloseGoingAway, int(binary.BigEndian.Uint16(payload)))
+	err := <-errCh
+	require.Equal(t, &CloseError{CloseGoingAway, "bye"}, err)
+	_, _, err2 := server.ReadMessage()
+	require.Equal(t, err, err2)
+	require.Equal(t, ErrCloseSent, server.WriteText("late"))
+}
+
+func TestConnProtocolErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		frame []byte
+		code  int
+	}{
+		{"unmasked", rawFrame(true, TextMessage, []byte("a"), false), CloseProtocolError},
+		{"reserved", append([]byte{finBit | rsvBits | TextMessage}, rawFrame(true, TextMessage, []byte("a"), true)[1:]...), CloseProtocolError},
+		{"opcode", rawFrame(true, 3, nil, true), CloseProtocolError},
+		{"continuation", rawFrame(true, continuationFrame, nil, true), CloseProtocolError},
+		{"fragmented control", rawFrame(false, PingMessage, nil, true), CloseProtocolError},
+		{"large control", rawFrame(true, PingMessage, make([]byte, 126), true), CloseProtocolError},
+		{"utf8", rawFrame(true, TextMessage, []byte{0xff, 0xfe}, true), CloseInvalidFramePayloadData},
+		{"limit", rawFrame(true, BinaryMessage, make([]byte, 11), true), CloseMessageTooBig},
+		{"close payload", rawFrame(true, CloseMessage, []byte{1}, true), CloseProtocolError},
+		{"close code", rawFrame(true, CloseMessage, closePayload(1005, ""), true), CloseProtocolError},
+		{"close reason", rawFrame(true, CloseMessage, closePayload(CloseNormalClosure, "\xff"), true), CloseInvalidFramePayloadData},
+	} {
+		server, client := testPipe(10)
+		go func(frame []byte) { _, _ = client.Write(frame) }(tc.frame)
+		errCh := make(chan error, 1)
+		go func() {
+			_, _, err := server.ReadMessage()
+			errCh <- err
+		}()
+		opcode, payload := readServerFrame(t, bufio.NewReader(client))
+		require.Equal(t, CloseMessage, opcode, tc.name)
+		require.Equal(t, tc.code, int(binary.BigEndian.Uint16(payload)), tc.name)
+		err := <-errCh
+		require.IsType(t, &ProtocolError{}, err, tc.name)
+		require.Equal(t, tc.code, err.(*ProtocolError).Code, tc.name)
+		require.Contains(t, err.Error(), "websocket: ")
+	}
+}
+
+func TestConnFragmentLimit(t *testing.T) {
+	server, client := testPipe(4)
+	go func() {
+		_, _ = client.Write(rawFrame(false, TextMessage, []byte("abc"), true))
+		_, _ = client.Write(rawFrame(true, continuationFrame, []byte("de"), true))
+	}()
+	go func() { _, _ = bufio.NewReader(client).ReadByte() }()
+	_, _, err := server.ReadMessage()
+	require.Equal(t, CloseMessageTooBig, err.(*ProtocolError).Code)
+}
+
+func TestConnWrite(t *testing.T) {
+	server, client := testPipe(0)
+	br := bufio.NewReader(client)
+	for _, size := range []int{5, 300, 70000} {
+		data := strings.Repeat("a", size)
+		go func() { require.Nil(t, server.WriteText(data)) }()
+		opcode, payload := readServerFrame(t, br)
+		require.Equal(t, TextMessage, opcode)
+		require.Equal(t, data, string(payload))
+	}
+	require.Error(t, server.WriteMessage(PingMessage, nil))
+	require.Equal(t, ErrInvalidControl, server.WriteControl(TextMessage, nil, time.Time{}))
+	require.Equal(t, ErrInvalidControl, server.WriteControl(PingMessage, make([]byte, 126), time.Time{}))
+	go func() { require.Nil(t, server.Ping([]byte("x"))) }()
+	opcode, payload := readServerFrame(t, br)
+	require.Equal(t, PingMessage, opcode)
+	require.Equal(t, "x", string(payload))
+	go func() { _ = server.Close() }()
+	opcode, payload = readServerFrame(t, br)
+	require.Equal(t, CloseMessage, opcode)
+	require.Equal(t, CloseNormalClosure, int(binary.BigEndian.Uint16(payload)))
+}
+
+type deadlineConn struct {
+	net.Conn
+	deadline time.Time
+}
+
+func (c *deadlineConn) SetWriteDeadline(t time.Time) error {
+	c.deadline = t
+	return c.Conn.SetWriteDeadline(t)
+}
+
+func TestConnWriteControlDeadline(t *testing.T) {
+	s, client := net.Pipe()
+	conn := &deadlineConn{Conn: s}
+	server := newConn(conn, nil, true, 0)
+	deadline := time.Now().Add(time.Hour)
+	require.Nil(t, server.SetWriteDeadline(deadline))
+	done := make(chan error)
+	go func() { done <- server.Ping([]byte("x")) }()
+	opcode, _ := readServerFrame(t, bufio.NewReader(client))
+	require.Equal(t, PingMessage, opcode)
+	require.Nil(t, <-done)
+	require.Equal(t, deadline, conn.deadline)
+}
+
+func TestValidCloseCode(t *testing.T) {
+	for code, valid := range map[int]bool{999: false, 1000: true, 1003: true, 1004: false, 1005: false, 1006: false, 1007: true, 1011: true, 1012: false, 2999: false, 3000: true, 4999: true, 5000: false} {
+		require.Equal(t, valid, validCloseCode(code), code)
+	}
+}
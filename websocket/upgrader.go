@@ -0,0 +1,177 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websocket
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultReadLimit the default maximum message size
+const DefaultReadLimit = 1 << 20
+
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// HandshakeError the upgrade request is invalid, the error response is already written
+type HandshakeError struct {
+	Status int
+	Msg    string
+}
+
+// Error implements
+func (e *HandshakeError) Error() string {
+	return "websocket: " + e.Msg
+}
+
+// Upgrader upgrades HTTP requests to WebSocket connections
+type Upgrader struct {
+	// ReadLimit the maximum message size, 0 for DefaultReadLimit, negative for no limit
+	ReadLimit int64
+	// Subprotocols the supported subprotocols in order of preference
+	Subprotocols []string
+	// Origins the allowed origins, e.g. https://example.com, "*" allows any.
+	// Without Origins and CheckOrigin only requests without Origin or of the same host are allowed.
+	Origins []string
+	// CheckOrigin reports whether the origin of the request is allowed, takes precedence over Origins
+	CheckOrigin func(r *http.Request) bool
+}
+
+func (u *Upgrader) checkOrigin(r *http.Request) bool {
+	if u.CheckOrigin != nil {
+		return u.CheckOrigin(r)
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, o := range u.Origins {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+	if len(u.Origins) > 0 {
+		return false
+	}
+	ou, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(ou.Host, r.Host)
+}
+
+func (u *Upgrader) subprotocol(r *http.Request) string {
+	for _, sp := range u.Subprotocols {
+		for _, requested := range headerTokens(r.Header, "Sec-WebSocket-Protocol") {
+			if sp == requested {
+				return sp
+			}
+		}
+	}
+	return ""
+}
+
+func (u *Upgrader) readLimit() int64 {
+	switch {
+	case u.ReadLimit == 0:
+		return DefaultReadLimit
+	case u.ReadLimit < 0:
+		return 0
+	}
+	return u.ReadLimit
+}
+
+// IsUpgrade reports whether the request asks for a WebSocket upgrade
+func IsUpgrade(r *http.Request) bool {
+	return tokenContains(r.Header, "Connection", "upgrade") && tokenContains(r.Header, "Upgrade", "websocket")
+}
+
+// Upgrade completes the handshake and hijacks the connection, header is added to the 101 response.
+//
+// On failure the error response is written to w and a *HandshakeError returned.
+func (u *Upgrader) Upgrade(w http.ResponseWriter, r *http.Request, header http.Header) (*Conn, error) {
+	fail := func(status int, msg string) (*Conn, error) {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, http.StatusText(status), status)
+		return nil, &HandshakeError{status, msg}
+	}
+	if r.Method != http.MethodGet {
+		return fail(http.StatusMethodNotAllowed, "upgrade requires GET")
+	}
+	if !IsUpgrade(r) {
+		return fail(http.StatusBadRequest, "not a websocket upgrade request")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return fail(http.StatusUpgradeRequired, "unsupported version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return fail(http.StatusBadRequest, "invalid Sec-WebSocket-Key")
+	}
+	if !u.checkOrigin(r) {
+		return fail(http.StatusForbidden, "origin not allowed")
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return fail(http.StatusInternalServerError, "response writer does not support hijacking")
+	}
+	netConn, brw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	// clear the deadlines of the http server, e.g. ReadTimeout
+	_ = netConn.SetDeadline(time.Time{})
+	c := newConn(netConn, brw.Reader, true, u.readLimit())
+	c.subprotocol = u.subprotocol(r)
+
+	var b strings.Builder
+	b.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	b.WriteString("Sec-WebSocket-Accept: " + AcceptKey(key) + "\r\n")
+	if c.subprotocol != "" {
+		b.WriteString("Sec-WebSocket-Protocol: " + c.subprotocol + "\r\n")
+	}
+	_ = header.Write(&b)
+	b.WriteString("\r\n")
+	if _, err = netConn.Write([]byte(b.String())); err != nil {
+		_ = netConn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// AcceptKey return the Sec-WebSocket-Accept of the Sec-WebSocket-Key
+func AcceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func headerTokens(h http.Header, name string) []string {
+	tokens := make([]string, 0)
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				tokens = append(tokens, t)
+			}
+		}
+	}
+	return tokens
+}
+
+func tokenContains(h http.Header, name, token string) bool {
+	for _, t := range headerTokens(h, name) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+// Copyright 2022 anoweb Author. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//      http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testServer(u *Upgrader) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := u.Upgrade(w, r, http.Header{"X-Upgraded": {"1"}})
+		if err != nil {
+			return
+		}
+		defer func() { _ = c.Close() }()
+		for {
+			mt, data, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			_ = c.WriteMessage(mt, data)
+		}
+	}))
+}
+
+func wsURL(s *httptest.Server) string {
+	return "ws" + strings.TrimPrefix(s.URL, "http")
+}
+
+func TestUpgrade(t *testing.T) {
+	s := testServer(&Upgrader{Subprotocols: []string{"chat", "json"}})
+	defer s.Close()
+	c, resp, err := Dial(wsURL(s), http.Header{"Sec-WebSocket-Protocol": {"json, chat"}})
+	require.Nil(t, err)
+	require.Equal(t, "1", resp.Header.Get("X-Upgraded"))
+	require.Equal(t, "chat", c.Subprotocol())
+	require.NotNil(t, c.LocalAddr())
+	require.NotNil(t, c.RemoteAddr())
+	require.Nil(t, c.WriteText("hello"))
+	mt, data, err := c.ReadMessage()
+	require.Nil(t, err)
+	require.Equal(t, TextMessage, mt)
+	require.Equal(t, "hello", string(data))
+	require.Nil(t, c.WriteMessage(BinaryMessage, make([]byte, 100000)))
+	_, data, err = c.ReadMessage()
+	require.Nil(t, err)
+	require.Len(t, data, 100000)
+	require.Nil(t, c.WriteClose(CloseNormalClosure, ""))
+	_, _, err = c.ReadMessage()
+	require.Equal(t, &CloseError{CloseNormalClosure, ""}, err)
+}
+
+func TestUpgradeReadLimit(t *testing.T) {
+	s := testServer(&Upgrader{ReadLimit: 8})
+	defer s.Close()
+	c, _, err := Dial(wsURL(s), nil)
+	require.Nil(t, err)
+	require.Nil(t, c.WriteText("0123456789"))
+	_, _, err = c.ReadMessage()
+	require.Equal(t, CloseMessageTooBig, err.(*CloseError).Code)
+}
+
+func TestUpgradeOrigin(t *testing.T) {
+	for _, tc := range []struct {
+		u      *Upgrader
+		origin string
+		ok     bool
+	}{
+		{&Upgrader{}, "", true},
+		{&Upgrader{}, "http://evil.com", false},
+		{&Upgrader{Origins: []string{"http://app.com"}}, "http://APP.com", true},
+		{&Upgrader{Origins: []string{"http://app.com"}}, "http://evil.com", false},
+		{&Upgrader{Origins: []string{"*"}}, "http://evil.com", true},
+		{&Upgrader{CheckOrigin: func(r *http.Request) bool { return false }}, "", false},
+	} {
+		s := testServer(tc.u)
+		header := http.Header{}
+		if tc.origin != "" {
+			header.Set("Origin", tc.origin)
+		}
+		c, resp, err := Dial(wsURL(s), header)
+		if tc.ok {
+			require.Nil(t, err, tc.origin)
+			_ = c.Close()
+		} else {
+			require.Equal(t, ErrBadHandshake, err, tc.origin)
+			require.Equal(t, http.StatusForbidden, resp.StatusCode)
+		}
+		s.Close()
+	}
+	s := testServer(&Upgrader{})
+	defer s.Close()
+	c, _, err := Dial(wsURL(s), http.Header{"Origin": {s.URL}})
+	require.Nil(t, err)
+	_ = c.Close()
+}
+
+func TestUpgradeBadRequest(t *testing.T) {
+	s := testServer(&Upgrader{})
+	defer s.Close()
+	do := func(method string, header http.Header) int {
+		req, _ := http.NewRequest(method, s.URL, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+	valid := func() http.Header {
+		return http.Header{
+			"Connection":            {"keep-alive, Upgrade"},
+			"Upgrade":               {"websocket"},
+			"Sec-Websocket-Version": {"13"},
+			"Sec-Websocket-Key":     {"dGhlIHNhbXBsZSBub25jZQ=="},
+		}
+	}
+	require.Equal(t, http.StatusMethodNotAllowed, do(http.MethodPost, valid()))
+	require.Equal(t, http.StatusBadRequest, do(http.MethodGet, nil))
+	h := valid()
+	h.Set("Sec-Websocket-Version", "8")
+	require.Equal(t, http.StatusUpgradeRequired, do(http.MethodGet, h))
+	h = valid()
+	h.Set("Sec-Websocket-Key", "short")
+	require.Equal(t, http.StatusBadRequest, do(http.MethodGet, h))
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header = valid()
+	_, err := (&Upgrader{}).Upgrade(rec, req, nil)
+	require.Equal(t, http.StatusInternalServerError, err.(*HandshakeError).Status)
+	require.Contains(t, err.Error(), "hijacking")
+}
+
+func TestAcceptKey(t *testing.T) {
+	// the sample of RFC 6455 section 1.3
+	require.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", AcceptKey("dGhlIHNhbXBsZSBub25jZQ=="))
+}
+
+func TestDial(t *testing.T) {
+	_, _, err := Dial("http://localhost", nil)
+	require.Error(t, err)
+	_, _, err = Dial("ws://%zz", nil)
+	require.Error(t, err)
+	s := httptest.NewServer(http.NotFoundHandler())
+	defer s.Close()
+	_, resp, err := Dial(wsURL(s), nil)
+	require.Equal(t, ErrBadHandshake, err)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}